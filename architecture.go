@@ -2,8 +2,8 @@ package main
 
 import (
 	"fmt"
-	"os"
 	"runtime"
+	"slices"
 )
 
 const (
@@ -13,6 +13,8 @@ const (
 	LINUX_PPCLE_64 = "linux-ppcle_64"
 	LINUX_ARM64    = "linux-aarch_64"
 	OSX_UNIVERSAL  = "osx-universal_binary"
+	OSX_X86_64     = "osx-x86_64"
+	OSX_ARM64      = "osx-aarch_64"
 	WIN32          = "win32"
 	WIN64          = "win64"
 
@@ -22,8 +24,37 @@ const (
 	WINDOWS        = "Windows"
 	ADDITION_CLANG = ".clang++-18"
 	ADDITION_GCC   = ".g++-13"
+
+	ADDITION_ANY_CLANG = ".clang++-*"
+	ADDITION_ANY_GCC   = ".g++-*"
+	ANY_VERSION        = "*"
+	ANY_PLATFORM       = "*"
 )
 
+// Platform, identified by GOOS and GOARCH values.
+type targetPlatform struct {
+	goos   string
+	goarch string
+}
+
+// All the platforms, supported by protogo.
+// Release assets checksums for all of them are recorded in lock file, so that the lock file can be shared between platforms.
+var supportedPlatforms = []targetPlatform{
+	{goos: "linux", goarch: "amd64"},
+	{goos: "linux", goarch: "386"},
+	{goos: "linux", goarch: "s390x"},
+	{goos: "linux", goarch: "ppc64le"},
+	{goos: "linux", goarch: "arm64"},
+	{goos: "darwin", goarch: "amd64"},
+	{goos: "darwin", goarch: "arm64"},
+	{goos: "windows", goarch: "386"},
+	{goos: "windows", goarch: "amd64"},
+	{goos: "windows", goarch: "arm64"},
+}
+
+// Flatc linux distributions.
+var flatcDistros = []string{"g++", "clang"}
+
 // Convert executable name to platform-specific file name.
 // Made for Windows support primarily.
 func getExecutableName(executable string) string {
@@ -43,18 +74,19 @@ func getExecutableName(executable string) string {
 // Check out [protobuf releases] for the list of supported version.
 // Check out [GO documentation] for possible GOOS and GOARCH values.
 //
+// Accept target GOOS and GOARCH values.
 // Return the platform string and error.
 //
 // [protobuf releases]: https://github.com/protocolbuffers/protobuf/releases
 // [GO documentation]: https://go.dev/doc/install/source#environment
-func getProtocOSandArch() (*string, error) {
+func getProtocOSandArch(goos, goarch string) (*string, error) {
 	var platform string
 	undefinedOS := false
 	undefinedArchitecture := false
 
-	switch runtime.GOOS {
+	switch goos {
 	case "linux":
-		switch runtime.GOARCH {
+		switch goarch {
 		case "amd64":
 			platform = LINUX_AMD64
 		case "386":
@@ -69,14 +101,14 @@ func getProtocOSandArch() (*string, error) {
 			undefinedArchitecture = true
 		}
 	case "darwin":
-		switch runtime.GOARCH {
+		switch goarch {
 		case "amd64", "arm64":
 			platform = OSX_UNIVERSAL
 		default:
 			undefinedArchitecture = true
 		}
 	case "windows":
-		switch runtime.GOARCH {
+		switch goarch {
 		case "386", "arm":
 			platform = WIN32
 		case "amd64", "arm64":
@@ -89,9 +121,9 @@ func getProtocOSandArch() (*string, error) {
 	}
 
 	if undefinedOS {
-		return nil, fmt.Errorf("the OS '%s' is either not supported by protogo or there are no protobuf binaries distributed for it", runtime.GOOS)
+		return nil, fmt.Errorf("the OS '%s' is either not supported by protogo or there are no protobuf binaries distributed for it", goos)
 	} else if undefinedArchitecture {
-		return nil, fmt.Errorf("the architecture '%s' is either not supported by protogo or there are no protobuf binaries distributed for it", runtime.GOARCH)
+		return nil, fmt.Errorf("the architecture '%s' is either not supported by protogo or there are no protobuf binaries distributed for it", goarch)
 	}
 
 	return &platform, nil
@@ -105,31 +137,28 @@ func getProtocOSandArch() (*string, error) {
 // Check out [flatc releases] for the list of supported version.
 // Check out [GO documentation] for possible GOOS and GOARCH values.
 //
+// Accept target GOOS and GOARCH values and linux distribution of flatc ("g++" or "clang", empty string for default).
 // Return the platform name (which is OS name and architecture), optional additional element of archive name and error.
 //
 // [GO documentation]: https://go.dev/doc/install/source#environment
 // [flatc releases]: https://github.com/google/flatbuffers/releases
-func getFlatcOSandAddition() (*string, string, error) {
+func getFlatcOSandAddition(goos, goarch, distro string) (*string, string, error) {
 	var system string
 	undefinedOS := false
 	undefinedArchitecture := false
 
 	addition := ""
-	switch runtime.GOOS {
+	switch goos {
 	case "linux":
 		system = LINUX_ANY
-		if value, ok := os.LookupEnv("PROTOGO_FLATC_DISTRO"); ok {
-			switch value {
-			case "g++":
-				addition = ADDITION_GCC
-			case "clang":
-				addition = ADDITION_CLANG
-			}
-		} else {
+		switch distro {
+		case "", "g++":
 			addition = ADDITION_GCC
+		case "clang":
+			addition = ADDITION_CLANG
 		}
 	case "darwin":
-		switch runtime.GOARCH {
+		switch goarch {
 		case "amd64":
 			system = MAC_INTEL
 		case "arm64":
@@ -144,10 +173,68 @@ func getFlatcOSandAddition() (*string, string, error) {
 	}
 
 	if undefinedOS {
-		return nil, addition, fmt.Errorf("the OS '%s' is either not supported by protogo or there are no protobuf binaries distributed for it", runtime.GOOS)
+		return nil, addition, fmt.Errorf("the OS '%s' is either not supported by protogo or there are no protobuf binaries distributed for it", goos)
 	} else if undefinedArchitecture {
-		return nil, addition, fmt.Errorf("the architecture '%s' is either not supported by protogo or there are no protobuf binaries distributed for it", runtime.GOARCH)
+		return nil, addition, fmt.Errorf("the architecture '%s' is either not supported by protogo or there are no protobuf binaries distributed for it", goarch)
 	}
 
 	return &system, addition, nil
 }
+
+// Determine release asset name patterns of protoc binaries, suitable for the current platform, ranked by preference.
+// The first pattern matches the native binary, the following ones match compatible binaries (e.g. "osx-x86_64" for Apple Silicon, run with Rosetta).
+// Patterns are in [path.Match] format, version is matched with wildcard.
+//
+// Return the asset name patterns list and error.
+func getProtocAssetPatterns() ([]string, error) {
+	platform, err := getProtocOSandArch(runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		return nil, err
+	}
+
+	platforms := []string{*platform}
+	if runtime.GOOS == "darwin" && runtime.GOARCH == "arm64" {
+		platforms = append(platforms, OSX_ARM64, OSX_X86_64)
+	} else if runtime.GOOS == "darwin" {
+		platforms = append(platforms, OSX_X86_64)
+	}
+
+	var patterns []string
+	for _, platform := range platforms {
+		patterns = append(patterns, fmt.Sprintf(PROTOC_ZIP_NAME, ANY_VERSION, platform))
+	}
+	return patterns, nil
+}
+
+// Determine release asset name patterns of flatc binaries, suitable for the current platform, ranked by preference.
+// Linux binaries are matched for any compiler version (e.g. both "g++-10" and "g++-13"), the requested distribution is preferred, but the other one is also accepted.
+// Mac binaries for the other architecture are accepted too (Apple Silicon runs Intel binaries with Rosetta, older releases only had Intel binaries named "Mac").
+// Patterns are in [path.Match] format.
+//
+// Accept linux distribution of flatc ("g++" or "clang", empty string for default).
+// Return the asset name patterns list and error.
+func getFlatcAssetPatterns(distro string) ([]string, error) {
+	system, addition, err := getFlatcOSandAddition(runtime.GOOS, runtime.GOARCH, distro)
+	if err != nil {
+		return nil, err
+	}
+
+	var patterns []string
+	switch *system {
+	case LINUX_ANY:
+		additions := []string{ADDITION_ANY_GCC, ADDITION_ANY_CLANG}
+		if addition == ADDITION_CLANG {
+			slices.Reverse(additions)
+		}
+		for _, addition := range additions {
+			patterns = append(patterns, fmt.Sprintf(FLATC_ZIP_NAME, LINUX_ANY, addition))
+		}
+	case MAC:
+		patterns = append(patterns, fmt.Sprintf(FLATC_ZIP_NAME, MAC, ""), fmt.Sprintf(FLATC_ZIP_NAME, MAC_INTEL, ""))
+	case MAC_INTEL:
+		patterns = append(patterns, fmt.Sprintf(FLATC_ZIP_NAME, MAC_INTEL, ""), fmt.Sprintf(FLATC_ZIP_NAME, MAC, ""))
+	default:
+		patterns = append(patterns, fmt.Sprintf(FLATC_ZIP_NAME, *system, addition))
+	}
+	return patterns, nil
+}
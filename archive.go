@@ -1,74 +1,324 @@
-// This whole file is based on the awesome [stackoverflow answer] by swtdrgn.
+// ZIP extraction in this file is based on the awesome [stackoverflow answer] by swtdrgn.
 //
 // [stackoverflow answer]: https://stackoverflow.com/a/24430720/9124072
 
 package main
 
 import (
+	"archive/tar"
 	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+
+	"github.com/ulikunitz/xz"
 )
 
-// Extract a file from ZIP archive.
+const (
+	ZIP_ARCHIVE_FORMAT    = "zip"
+	TAR_ARCHIVE_FORMAT    = "tar"
+	TAR_GZ_ARCHIVE_FORMAT = "tar.gz"
+	TAR_XZ_ARCHIVE_FORMAT = "tar.xz"
+
+	ZIP_MAGIC        = "PK\x03\x04"
+	ZIP_EMPTY_MAGIC  = "PK\x05\x06"
+	GZIP_MAGIC       = "\x1f\x8b"
+	XZ_MAGIC         = "\xfd7zXZ\x00"
+	TAR_MAGIC        = "ustar"
+	TAR_MAGIC_OFFSET = 257
+
+	MAX_ARCHIVE_ENTRIES     = 100000
+	MAX_ARCHIVE_SIZE        = 1 << 30
+	ARCHIVE_OWNER_FILE_MODE = 0600
+	ARCHIVE_OWNER_DIR_MODE  = 0700
+	ARCHIVE_EXECUTABLE_MODE = 0755
+)
+
+// Archive file name suffixes, used for telling archive release assets from executable ones.
+var archiveSuffixes = []string{ZIP_ARCHIVE_SUFFIX, TAR_ARCHIVE_SUFFIX, TAR_GZ_ARCHIVE_SUFFIX, TGZ_ARCHIVE_SUFFIX, TAR_XZ_ARCHIVE_SUFFIX, TXZ_ARCHIVE_SUFFIX}
+
+// Remaining archive extraction budget: number of entries and total uncompressed size in bytes.
+// Protects against archive bombs, the budget is shared between all the entries of one archive.
+type extractionBudget struct {
+	entries int
+	size    int64
+}
+
+// Create archive extraction budget with the default limits.
+//
+// Return extraction budget pointer.
+func newExtractionBudget() *extractionBudget {
+	return &extractionBudget{entries: MAX_ARCHIVE_ENTRIES, size: MAX_ARCHIVE_SIZE}
+}
+
+// Take one archive entry from the budget.
+//
+// Return error (if the entry limit is exceeded).
+func (budget *extractionBudget) takeEntry() error {
+	if budget.entries <= 0 {
+		return fmt.Errorf("archive contains more than %d entries", MAX_ARCHIVE_ENTRIES)
+	}
+	budget.entries--
+	return nil
+}
+
+// Copy archive entry contents, taking its size from the budget.
+// Copying stops as soon as the size limit is exceeded, so that the declared entry sizes are never trusted.
+//
+// Accept destination writer and entry contents reader.
+// Return error (if the size limit is exceeded or copying fails).
+func (budget *extractionBudget) copy(dest io.Writer, src io.Reader) error {
+	n, err := io.CopyN(dest, src, budget.size+1)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	budget.size -= n
+	if budget.size < 0 {
+		return fmt.Errorf("archive uncompressed size exceeds %d bytes", MAX_ARCHIVE_SIZE)
+	}
+	return nil
+}
+
+// Check whether a path is located inside of a directory (or is the directory itself).
+// Both paths are expected to be absolute and clean.
+//
+// Accept directory path and path to check.
+// Return true if the path is inside of the directory.
+func isInsideDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// Prepare archive extraction destination directory: create it and resolve its real absolute path.
+//
+// Accept destination extraction directory path.
+// Return real absolute destination directory path and error.
+func prepareExtractionDir(dest string) (string, error) {
+	err := os.MkdirAll(dest, os.ModePerm)
+	if err != nil {
+		return "", fmt.Errorf("error making directory %s: %v", dest, err)
+	}
+
+	absoluteDest, err := filepath.Abs(dest)
+	if err != nil {
+		return "", fmt.Errorf("error resolving path %s: %v", dest, err)
+	}
+
+	realDest, err := filepath.EvalSymlinks(absoluteDest)
+	if err != nil {
+		return "", fmt.Errorf("error resolving path %s: %v", absoluteDest, err)
+	}
+
+	return realDest, nil
+}
+
+// Resolve archive entry path inside of the destination directory.
+// The entry path should stay inside of the destination directory both lexically and after resolving symbolic links of its parent directories.
 // Make all the parent directories, if needed.
 //
-// Accept ZIP file and destination path.
-// Return error.
-func extractFile(file *zip.File, path string) error {
-	fdir := filepath.Dir(path)
+// Accept real absolute destination directory path and archive entry name.
+// Return real entry path (with real parent directory) and error.
+func resolveEntryPath(dest, name string) (string, error) {
+	fpath := filepath.Join(dest, name)
+	if !isInsideDir(dest, fpath) {
+		return "", fmt.Errorf("archive entry %s is outside of destination directory %s", name, dest)
+	} else if fpath == dest {
+		return dest, nil
+	}
 
-	reader, err := file.Open()
+	fdir := filepath.Dir(fpath)
+	err := os.MkdirAll(fdir, os.ModePerm)
 	if err != nil {
-		return fmt.Errorf("error opening object %s: %v", file.Name, err)
-	} else {
-		defer reader.Close()
+		return "", fmt.Errorf("error making directory %s: %v", fdir, err)
 	}
 
-	err = os.MkdirAll(fdir, os.ModePerm)
+	realDir, err := filepath.EvalSymlinks(fdir)
 	if err != nil {
-		return fmt.Errorf("error making directory %s: %v", fdir, err)
+		return "", fmt.Errorf("error resolving path %s: %v", fdir, err)
+	} else if !isInsideDir(dest, realDir) {
+		return "", fmt.Errorf("archive entry %s is linked outside of destination directory %s", name, dest)
 	}
 
-	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.ModePerm)
+	return filepath.Join(realDir, filepath.Base(fpath)), nil
+}
+
+// Write archive directory entry.
+// Owner is always allowed to access the directory, so that its contents can be extracted and removed later.
+//
+// Accept directory path and stored directory mode.
+// Return error.
+func writeEntryDir(path string, mode os.FileMode) error {
+	err := os.MkdirAll(path, mode.Perm()|ARCHIVE_OWNER_DIR_MODE)
+	if err != nil {
+		return fmt.Errorf("error making directory %s: %v", path, err)
+	}
+	return nil
+}
+
+// Write archive regular file entry, preserving its permission bits.
+// Owner is always allowed to read and write the file, so that it can be replaced and removed later.
+// Any existing file (or link) is removed and the file is created exclusively, so that writing never follows links.
+//
+// Accept file path, entry contents reader, stored file mode and extraction budget pointer.
+// Return error.
+func writeEntryFile(path string, reader io.Reader, mode os.FileMode, budget *extractionBudget) error {
+	err := removeEntryFile(path)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode.Perm()|ARCHIVE_OWNER_FILE_MODE)
 	if err != nil {
 		return fmt.Errorf("error opening file %s: %v", path, err)
 	} else {
 		defer f.Close()
 	}
 
-	_, err = io.Copy(f, reader)
+	err = budget.copy(f, reader)
 	if err != nil {
-		return fmt.Errorf("error copying file contents %s: %v", file.Name, err)
+		return fmt.Errorf("error copying file contents %s: %v", path, err)
+	}
+
+	return nil
+}
+
+// Remove existing file (or link) before replacing it with another entry.
+// Directories are never removed.
+//
+// Accept file path.
+// Return error.
+func removeEntryFile(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("error reading file %s: %v", path, err)
+	} else if info.IsDir() {
+		return fmt.Errorf("error replacing directory %s with a file", path)
 	}
 
+	err = os.Remove(path)
+	if err != nil {
+		return fmt.Errorf("error removing file %s: %v", path, err)
+	}
 	return nil
 }
 
+// Write archive symbolic link entry.
+// The link target should be relative and should point inside of the destination directory (after resolving the real link location).
+// The target is cleaned before the link is created, so that it can not traverse other links with "..".
+//
+// Accept real absolute destination directory path, link path (with real parent directory) and link target.
+// Return error.
+func writeEntrySymlink(dest, path, target string) error {
+	cleanTarget := filepath.Clean(filepath.FromSlash(target))
+	if filepath.IsAbs(cleanTarget) || filepath.VolumeName(cleanTarget) != "" {
+		return fmt.Errorf("symbolic link %s has absolute target %s", path, target)
+	} else if !isInsideDir(dest, filepath.Join(filepath.Dir(path), cleanTarget)) {
+		return fmt.Errorf("symbolic link %s target %s is outside of destination directory %s", path, target, dest)
+	}
+
+	err := removeEntryFile(path)
+	if err != nil {
+		return err
+	}
+
+	err = os.Symlink(cleanTarget, path)
+	if err != nil {
+		return fmt.Errorf("error making symbolic link %s: %v", path, err)
+	}
+
+	return nil
+}
+
+// Write archive hard link entry.
+// The link target is an archive entry name, it should be located inside of the destination directory and should be a regular file.
+// Symbolic link targets are rejected, since hard link to a symbolic link may point outside of the destination directory.
+//
+// Accept real absolute destination directory path, link path (with real parent directory) and link target entry name.
+// Return error.
+func writeEntryHardlink(dest, path, target string) error {
+	targetPath, err := resolveEntryPath(dest, target)
+	if err != nil {
+		return fmt.Errorf("hard link %s target error: %v", path, err)
+	}
+
+	info, err := os.Lstat(targetPath)
+	if err != nil {
+		return fmt.Errorf("error reading hard link %s target %s: %v", path, target, err)
+	} else if !info.Mode().IsRegular() {
+		return fmt.Errorf("hard link %s target %s is not a regular file", path, target)
+	}
+
+	err = removeEntryFile(path)
+	if err != nil {
+		return err
+	}
+
+	err = os.Link(targetPath, path)
+	if err != nil {
+		return fmt.Errorf("error making hard link %s: %v", path, err)
+	}
+
+	return nil
+}
+
+// Extract a file from ZIP archive.
+// Symbolic links are stored in ZIP archives as files, containing link target.
+//
+// Accept ZIP file, real absolute destination directory path, entry path and extraction budget pointer.
+// Return error.
+func extractFile(file *zip.File, dest, path string, budget *extractionBudget) error {
+	reader, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("error opening object %s: %v", file.Name, err)
+	} else {
+		defer reader.Close()
+	}
+
+	if file.Mode()&os.ModeSymlink != 0 {
+		var target strings.Builder
+		err = budget.copy(&target, reader)
+		if err != nil {
+			return fmt.Errorf("error reading symbolic link %s: %v", file.Name, err)
+		}
+		return writeEntrySymlink(dest, path, target.String())
+	}
+
+	return writeEntryFile(path, reader, file.Mode(), budget)
+}
+
 // Extract any item from ZIP archive.
 // If it is a directory, create corresponding directory in the target location.
-// If it is a file, extract this file.
+// If it is a file or a symbolic link, extract it.
 //
-// Accept ZIP file and destination path.
+// Accept ZIP file, real absolute destination directory path and extraction budget pointer.
 // Return error.
-func extractItem(file *zip.File, dest string) error {
-	fpath, err := filepath.Abs(filepath.Join(dest, file.Name))
+func extractItem(file *zip.File, dest string, budget *extractionBudget) error {
+	err := budget.takeEntry()
+	if err != nil {
+		return err
+	}
+
+	fpath, err := resolveEntryPath(dest, file.Name)
 	if err != nil {
-		return fmt.Errorf("error resolving path: %s", fpath)
-	} else if !strings.Contains(fpath, dest) {
-		return fmt.Errorf("error extracting path: %s (%v)", fpath, dest)
+		return err
 	}
 
 	if file.FileInfo().IsDir() {
-		err := os.MkdirAll(fpath, os.ModePerm)
+		err := writeEntryDir(fpath, file.Mode())
 		if err != nil {
-			return fmt.Errorf("error making directory %s: %v", fpath, err)
+			return err
 		}
 	} else {
-		err := extractFile(file, fpath)
+		err := extractFile(file, dest, fpath, budget)
 		if err != nil {
 			return fmt.Errorf("error extracting file %s: %v", fpath, err)
 		}
@@ -78,21 +328,25 @@ func extractItem(file *zip.File, dest string) error {
 }
 
 // Extract ZIP archive.
-// Set current user permissions to all the extracted files and directories.
+// Preserve permission bits and symbolic links, stored in the archive.
 // Replace any existing files, if they are found.
 //
-// Accept source ZIP archive path and destination extraction directory path.
+// Accept ZIP archive contents, archive size, archive name (for error messages) and destination extraction directory path.
 // Return error.
-func unzip(src, dest string) error {
-	reader, err := zip.OpenReader(src)
+func unzip(data io.ReaderAt, size int64, name, dest string) error {
+	reader, err := zip.NewReader(data, size)
 	if err != nil {
-		return fmt.Errorf("error opening archive reader %s: %v", src, err)
-	} else {
-		defer reader.Close()
+		return fmt.Errorf("error opening archive reader %s: %v", name, err)
+	}
+
+	realDest, err := prepareExtractionDir(dest)
+	if err != nil {
+		return err
 	}
 
+	budget := newExtractionBudget()
 	for _, f := range reader.File {
-		err = extractItem(f, dest)
+		err = extractItem(f, realDest, budget)
 		if err != nil {
 			return fmt.Errorf("error extracting file %s: %v", f.Name, err)
 		}
@@ -100,3 +354,196 @@ func unzip(src, dest string) error {
 
 	return nil
 }
+
+// Extract any item from TAR archive.
+// Directories, regular files, symbolic and hard links are extracted, all the other items (devices, FIFOs, etc.) are skipped.
+//
+// Accept TAR header, TAR reader (positioned at the item contents), real absolute destination directory path and extraction budget pointer.
+// Return error.
+func extractTarItem(header *tar.Header, reader io.Reader, dest string, budget *extractionBudget) error {
+	err := budget.takeEntry()
+	if err != nil {
+		return err
+	}
+
+	fpath, err := resolveEntryPath(dest, header.Name)
+	if err != nil {
+		return err
+	}
+
+	switch header.Typeflag {
+	case tar.TypeDir:
+		return writeEntryDir(fpath, header.FileInfo().Mode())
+	case tar.TypeReg:
+		return writeEntryFile(fpath, reader, header.FileInfo().Mode(), budget)
+	case tar.TypeSymlink:
+		return writeEntrySymlink(dest, fpath, header.Linkname)
+	case tar.TypeLink:
+		return writeEntryHardlink(dest, fpath, header.Linkname)
+	default:
+		return nil
+	}
+}
+
+// Extract TAR archive from a stream.
+// Preserve permission bits, symbolic and hard links, stored in the archive.
+// Replace any existing files, if they are found.
+//
+// Accept TAR archive stream, archive name (for error messages) and destination extraction directory path.
+// Return error.
+func untar(stream io.Reader, name, dest string) error {
+	realDest, err := prepareExtractionDir(dest)
+	if err != nil {
+		return err
+	}
+
+	budget := newExtractionBudget()
+	reader := tar.NewReader(stream)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return fmt.Errorf("error reading archive %s: %v", name, err)
+		}
+
+		err = extractTarItem(header, reader, realDest, budget)
+		if err != nil {
+			return fmt.Errorf("error extracting file %s: %v", header.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// Extract gzip-compressed TAR archive from a stream.
+//
+// Accept TAR.GZ archive stream, archive name (for error messages) and destination extraction directory path.
+// Return error.
+func untarGzip(stream io.Reader, name, dest string) error {
+	decompressed, err := gzip.NewReader(stream)
+	if err != nil {
+		return fmt.Errorf("error opening archive decompressor %s: %v", name, err)
+	} else {
+		defer decompressed.Close()
+	}
+
+	return untar(decompressed, name, dest)
+}
+
+// Extract xz-compressed TAR archive from a stream.
+//
+// Accept TAR.XZ archive stream, archive name (for error messages) and destination extraction directory path.
+// Return error.
+func untarXz(stream io.Reader, name, dest string) error {
+	decompressed, err := xz.NewReader(stream)
+	if err != nil {
+		return fmt.Errorf("error opening archive decompressor %s: %v", name, err)
+	}
+
+	return untar(decompressed, name, dest)
+}
+
+// Check whether release asset is an archive (ZIP, TAR, TAR.GZ or TAR.XZ), judging by its name.
+//
+// Accept asset name.
+// Return true if the asset is an archive.
+func isArchiveAsset(name string) bool {
+	lowerName := strings.ToLower(name)
+	return slices.ContainsFunc(archiveSuffixes, func(suffix string) bool { return strings.HasSuffix(lowerName, suffix) })
+}
+
+// Detect archive format by its contents ("magic" bytes at the beginning of the archive).
+//
+// Accept archive contents and archive name (for error messages).
+// Return archive format (one of "*_ARCHIVE_FORMAT" constants or empty string if the contents are not a known archive) and error.
+func detectArchiveFormat(data io.ReaderAt, name string) (string, error) {
+	header := make([]byte, TAR_MAGIC_OFFSET+len(TAR_MAGIC))
+	n, err := data.ReadAt(header, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("error reading archive %s: %v", name, err)
+	}
+	header = header[:n]
+
+	switch {
+	case bytes.HasPrefix(header, []byte(ZIP_MAGIC)) || bytes.HasPrefix(header, []byte(ZIP_EMPTY_MAGIC)):
+		return ZIP_ARCHIVE_FORMAT, nil
+	case bytes.HasPrefix(header, []byte(GZIP_MAGIC)):
+		return TAR_GZ_ARCHIVE_FORMAT, nil
+	case bytes.HasPrefix(header, []byte(XZ_MAGIC)):
+		return TAR_XZ_ARCHIVE_FORMAT, nil
+	case len(header) == TAR_MAGIC_OFFSET+len(TAR_MAGIC) && bytes.HasPrefix(header[TAR_MAGIC_OFFSET:], []byte(TAR_MAGIC)):
+		return TAR_ARCHIVE_FORMAT, nil
+	default:
+		return "", nil
+	}
+}
+
+// Extract archive of any supported format (ZIP, TAR, TAR.GZ or TAR.XZ), detecting the format by archive contents.
+// Archive contents are read in place (either from memory or from a temporary file), so that extraction starts right after download.
+// All the formats share the same path traversal protection.
+//
+// Accept archive contents, archive size, archive name (for error messages) and destination extraction directory path.
+// Return error.
+func extractArchive(data io.ReaderAt, size int64, name, dest string) error {
+	format, err := detectArchiveFormat(data, name)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case ZIP_ARCHIVE_FORMAT:
+		return unzip(data, size, name, dest)
+	case TAR_GZ_ARCHIVE_FORMAT:
+		return untarGzip(io.NewSectionReader(data, 0, size), name, dest)
+	case TAR_XZ_ARCHIVE_FORMAT:
+		return untarXz(io.NewSectionReader(data, 0, size), name, dest)
+	case TAR_ARCHIVE_FORMAT:
+		return untar(io.NewSectionReader(data, 0, size), name, dest)
+	default:
+		return fmt.Errorf("unknown archive format of %s", name)
+	}
+}
+
+// Write a single file and make it executable.
+// Made for release assets, that are distributed as plain executables, not archives.
+//
+// Accept file contents reader and destination file path.
+// Return error.
+func writeExecutable(reader io.Reader, dest string) error {
+	f, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, ARCHIVE_EXECUTABLE_MODE)
+	if err != nil {
+		return fmt.Errorf("error opening file %s: %v", dest, err)
+	} else {
+		defer f.Close()
+	}
+
+	_, err = io.Copy(f, reader)
+	if err != nil {
+		return fmt.Errorf("error copying file contents %s: %v", dest, err)
+	}
+
+	return nil
+}
+
+// Make extracted file executable.
+// ZIP archives, created without Unix permission bits, are extracted with no executable bits set.
+// The file should be a regular file (or a symbolic link to it, symbolic links are verified to point inside of the extraction directory).
+//
+// Accept extracted file path.
+// Return error.
+func makeExecutable(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("error reading executable %s: %v", path, err)
+	} else if !info.Mode().IsRegular() {
+		return fmt.Errorf("executable %s is not a regular file", path)
+	}
+
+	err = os.Chmod(path, ARCHIVE_EXECUTABLE_MODE)
+	if err != nil {
+		return fmt.Errorf("error making file %s executable: %v", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ulikunitz/xz"
+)
+
+// Archive entry for making test archives in memory.
+type testArchiveEntry struct {
+	name     string
+	typeflag byte
+	linkname string
+	content  string
+}
+
+// Make TAR archive with the given entries in memory.
+func makeTestTar(t *testing.T, entries []testArchiveEntry) []byte {
+	var buffer bytes.Buffer
+	writer := tar.NewWriter(&buffer)
+	for _, entry := range entries {
+		header := &tar.Header{Typeflag: entry.typeflag, Name: entry.name, Linkname: entry.linkname, Mode: 0755, Size: int64(len(entry.content))}
+		if err := writer.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Write([]byte(entry.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+func TestExtractArchiveFormats(t *testing.T) {
+	files := map[string]string{"bin/protoc": "protoc", "include/google/protobuf/any.proto": "syntax = \"proto3\";"}
+	var entries []testArchiveEntry
+	for name, content := range files {
+		entries = append(entries, testArchiveEntry{name: name, typeflag: tar.TypeReg, content: content})
+	}
+	archive := makeTestTar(t, entries)
+
+	var gzipped bytes.Buffer
+	gzipWriter := gzip.NewWriter(&gzipped)
+	gzipWriter.Write(archive)
+	gzipWriter.Close()
+
+	var xzipped bytes.Buffer
+	xzWriter, err := xz.NewWriter(&xzipped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xzWriter.Write(archive)
+	xzWriter.Close()
+
+	var zipped bytes.Buffer
+	zipWriter := zip.NewWriter(&zipped)
+	for name, content := range files {
+		writer, err := zipWriter.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writer.Write([]byte(content))
+	}
+	zipWriter.Close()
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "archive.tar", data: archive},
+		{name: "archive.tar.gz", data: gzipped.Bytes()},
+		{name: "archive.tar.xz", data: xzipped.Bytes()},
+		{name: "archive.zip", data: zipped.Bytes()},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dest := t.TempDir()
+			err := extractArchive(bytes.NewReader(test.data), int64(len(test.data)), test.name, dest)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for name, content := range files {
+				data, err := os.ReadFile(filepath.Join(dest, name))
+				if err != nil {
+					t.Errorf("file %s not extracted: %v", name, err)
+				} else if string(data) != content {
+					t.Errorf("file %s content '%s', expected '%s'", name, data, content)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractArchiveCorruptedXz(t *testing.T) {
+	data := append([]byte(XZ_MAGIC), bytes.Repeat([]byte{0}, 64)...)
+	err := extractArchive(bytes.NewReader(data), int64(len(data)), "archive.tar.xz", t.TempDir())
+	if err == nil {
+		t.Error("expected error for corrupted xz archive")
+	}
+}
+
+func TestIsInsideDir(t *testing.T) {
+	dir := filepath.FromSlash("/cache/protoc")
+
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{path: "/cache/protoc", expected: true},
+		{path: "/cache/protoc/bin/protoc", expected: true},
+		{path: "/cache/protoc/..protoc", expected: true},
+		{path: "/cache", expected: false},
+		{path: "/cache/protoc-25.1", expected: false},
+		{path: "/cache/protoc/../flatc", expected: false},
+		{path: "/etc/passwd", expected: false},
+	}
+
+	for _, test := range tests {
+		path := filepath.Clean(filepath.FromSlash(test.path))
+		if inside := isInsideDir(dir, path); inside != test.expected {
+			t.Errorf("isInsideDir(%s, %s) = %v, expected %v", dir, path, inside, test.expected)
+		}
+	}
+}
+
+func TestResolveEntryPath(t *testing.T) {
+	dest, err := prepareExtractionDir(filepath.Join(t.TempDir(), "dest"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(dest, "outside")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("bin", filepath.Join(dest, "inside")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		entry    string
+		expected string
+		fails    bool
+	}{
+		{name: "plain entry", entry: "bin/protoc", expected: filepath.Join(dest, "bin", "protoc")},
+		{name: "destination itself", entry: "./", expected: dest},
+		{name: "lexically clean traversal", entry: "bin/../include/any.proto", expected: filepath.Join(dest, "include", "any.proto")},
+		{name: "absolute entry stays inside", entry: "/bin/protoc", expected: filepath.Join(dest, "bin", "protoc")},
+		{name: "link inside destination", entry: "inside/protoc", expected: filepath.Join(dest, "bin", "protoc")},
+		{name: "parent traversal", entry: "../evil", fails: true},
+		{name: "nested parent traversal", entry: "bin/../../evil", fails: true},
+		{name: "link outside destination", entry: "outside/evil", fails: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path, err := resolveEntryPath(dest, test.entry)
+			if test.fails && err == nil {
+				t.Errorf("expected error, got path %s", path)
+			} else if !test.fails && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			} else if path != test.expected {
+				t.Errorf("got path %s, expected %s", path, test.expected)
+			}
+		})
+	}
+}
+
+func TestUntarLinks(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []testArchiveEntry
+		files   map[string]string
+		fails   bool
+	}{
+		{
+			name: "links inside destination",
+			entries: []testArchiveEntry{
+				{name: "bin/protoc-25.1", typeflag: tar.TypeReg, content: "protoc"},
+				{name: "bin/protoc", typeflag: tar.TypeSymlink, linkname: "protoc-25.1"},
+				{name: "protoc", typeflag: tar.TypeLink, linkname: "bin/protoc-25.1"},
+			},
+			files: map[string]string{"bin/protoc": "protoc", "protoc": "protoc"},
+		},
+		{
+			name:    "symbolic link with absolute target",
+			entries: []testArchiveEntry{{name: "evil", typeflag: tar.TypeSymlink, linkname: "/etc/passwd"}},
+			fails:   true,
+		},
+		{
+			name:    "symbolic link outside destination",
+			entries: []testArchiveEntry{{name: "d/evil", typeflag: tar.TypeSymlink, linkname: "../../x"}},
+			fails:   true,
+		},
+		{
+			name: "file written through symbolic link",
+			entries: []testArchiveEntry{
+				{name: "d1/d2/s", typeflag: tar.TypeSymlink, linkname: "../../x"},
+				{name: "d1/d2/s", typeflag: tar.TypeReg, content: "evil"},
+			},
+			files: map[string]string{"d1/d2/s": "evil"},
+		},
+		{
+			name: "hard link to symbolic link",
+			entries: []testArchiveEntry{
+				{name: "d1/d2/s", typeflag: tar.TypeSymlink, linkname: "../../x"},
+				{name: "h", typeflag: tar.TypeLink, linkname: "d1/d2/s"},
+				{name: "h", typeflag: tar.TypeReg, content: "evil"},
+			},
+			fails: true,
+		},
+		{
+			name:    "hard link outside destination",
+			entries: []testArchiveEntry{{name: "h", typeflag: tar.TypeLink, linkname: "../x"}},
+			fails:   true,
+		},
+		{
+			name:    "file outside destination",
+			entries: []testArchiveEntry{{name: "../x", typeflag: tar.TypeReg, content: "evil"}},
+			fails:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root := t.TempDir()
+			dest := filepath.Join(root, "a", "b")
+			archive := makeTestTar(t, test.entries)
+
+			err := untar(bytes.NewReader(archive), "archive.tar", dest)
+			if test.fails && err == nil {
+				t.Error("expected error")
+			} else if !test.fails && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			for _, outside := range []string{filepath.Join(root, "x"), filepath.Join(root, "a", "x")} {
+				if _, err := os.Lstat(outside); err == nil {
+					t.Errorf("file %s written outside of destination directory", outside)
+				}
+			}
+			for name, content := range test.files {
+				data, err := os.ReadFile(filepath.Join(dest, name))
+				if err != nil {
+					t.Errorf("file %s not extracted: %v", name, err)
+				} else if string(data) != content {
+					t.Errorf("file %s content '%s', expected '%s'", name, data, content)
+				}
+			}
+		})
+	}
+}
+
+func TestUnzipOutsideDestination(t *testing.T) {
+	var zipped bytes.Buffer
+	writer := zip.NewWriter(&zipped)
+	entry, err := writer.Create("../../evil")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry.Write([]byte("evil"))
+	writer.Close()
+
+	root := t.TempDir()
+	err = unzip(bytes.NewReader(zipped.Bytes()), int64(zipped.Len()), "archive.zip", filepath.Join(root, "a", "b"))
+	if err == nil {
+		t.Error("expected error")
+	}
+	if _, err := os.Lstat(filepath.Join(root, "evil")); err == nil {
+		t.Error("file written outside of destination directory")
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	AUTHORIZATION_HEADER = "Authorization"
+	MAX_REDIRECTS        = 10
+)
+
+// Hosts, GitHub token is sent to.
+// Release asset downloads are redirected from these hosts to CDN hosts, that never receive the token.
+var githubAuthHosts = []string{GITHUB_API_HOST, GITHUB_HOST}
+
+// Configured per-host credentials (host to bearer token map).
+var hostCredentials = map[string]string{}
+
+// Find credentials for the given URL host.
+// Configured per-host credentials are checked first (either for host with port or for host name only),
+// their values may reference environment variables (e.g. "${MIRROR_TOKEN}").
+// GitHub token is used for GitHub hosts only.
+//
+// Accept target URL pointer.
+// Return bearer token, its source description (for logging) and boolean flag, whether the credentials were found.
+func getHostCredentials(target *url.URL) (string, string, bool) {
+	for _, host := range []string{strings.ToLower(target.Host), strings.ToLower(target.Hostname())} {
+		if token, ok := hostCredentials[host]; ok {
+			return os.ExpandEnv(token), fmt.Sprintf("configured credentials for %s", host), true
+		}
+	}
+
+	if slices.Contains(githubAuthHosts, strings.ToLower(target.Hostname())) {
+		return getGitHubToken()
+	}
+
+	return "", "", false
+}
+
+// Set "Authorization" header of the request according to the request host.
+// Any existing "Authorization" header is removed, so that credentials never leak to a host they were not configured for.
+//
+// Accept HTTP request pointer.
+func setRequestAuthorization(req *http.Request) {
+	req.Header.Del(AUTHORIZATION_HEADER)
+	if token, source, ok := getHostCredentials(req.URL); ok {
+		logrus.Debugf("Authorization for %s set from: %s", req.URL.Host, source)
+		req.Header.Set(AUTHORIZATION_HEADER, fmt.Sprintf("Bearer %s", token))
+	} else {
+		logrus.Debugf("Authorization for %s not set!", req.URL.Host)
+	}
+}
+
+// Check HTTP redirect: limit number of redirects and re-evaluate authorization for cross-host redirects.
+// Is used as HTTP client "CheckRedirect" function.
+//
+// Accept redirect HTTP request pointer and the previous requests.
+// Return error.
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= MAX_REDIRECTS {
+		return errors.New("stopped after too many redirects")
+	}
+
+	if previous := via[len(via)-1]; !strings.EqualFold(req.URL.Host, previous.URL.Host) {
+		logrus.Debugf("Request redirected from %s to %s", previous.URL.Host, req.URL.Host)
+		setRequestAuthorization(req)
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"testing"
+)
+
+func TestGetHostCredentials(t *testing.T) {
+	t.Setenv("PROTOGO_GITHUB_BEARER_TOKEN", "")
+	t.Setenv("GITHUB_TOKEN", "github-token")
+	t.Setenv("MIRROR_TOKEN", "mirror-token")
+	t.Setenv("NETRC", filepath.Join(t.TempDir(), "missing"))
+
+	defer func(credentials map[string]string) { hostCredentials = credentials }(hostCredentials)
+	hostCredentials = map[string]string{"mirror.example.com": "${MIRROR_TOKEN}", "proxy.example.com:8443": "proxy-token"}
+
+	tests := []struct {
+		name     string
+		url      string
+		expected string
+		found    bool
+	}{
+		{name: "GitHub API host", url: "https://api.github.com/repos/protocolbuffers/protobuf/releases", expected: "github-token", found: true},
+		{name: "GitHub host", url: "https://GitHub.com/protocolbuffers/protobuf/releases/download/v25.1/protoc.zip", expected: "github-token", found: true},
+		{name: "GitHub CDN host", url: "https://objects.githubusercontent.com/github-production-release-asset"},
+		{name: "GitHub lookalike host", url: "https://github.com.example.com/protoc.zip"},
+		{name: "configured host with environment variable", url: "https://mirror.example.com/protoc.zip", expected: "mirror-token", found: true},
+		{name: "configured host with port", url: "https://proxy.example.com:8443/protoc.zip", expected: "proxy-token", found: true},
+		{name: "configured host on other port", url: "https://proxy.example.com/protoc.zip"},
+		{name: "unknown host", url: "https://example.com/protoc.zip"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			target, err := url.Parse(test.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			token, _, found := getHostCredentials(target)
+			if found != test.found || token != test.expected {
+				t.Errorf("got ('%s', %v), expected ('%s', %v)", token, found, test.expected, test.found)
+			}
+		})
+	}
+}
+
+func TestCheckRedirect(t *testing.T) {
+	t.Setenv("PROTOGO_GITHUB_BEARER_TOKEN", "")
+	t.Setenv("GITHUB_TOKEN", "github-token")
+
+	previous, _ := http.NewRequest(GET_HTTP, "https://api.github.com/repos/owner/repo/releases/assets/1", nil)
+	setRequestAuthorization(previous)
+
+	tests := []struct {
+		name       string
+		url        string
+		authorized bool
+	}{
+		{name: "same host keeps authorization", url: "https://api.github.com/repos/owner/repo/releases/assets/2", authorized: true},
+		{name: "cross host drops authorization", url: "https://objects.githubusercontent.com/asset", authorized: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(GET_HTTP, test.url, nil)
+			req.Header = previous.Header.Clone()
+
+			err := checkRedirect(req, []*http.Request{previous})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if authorized := req.Header.Get(AUTHORIZATION_HEADER) != ""; authorized != test.authorized {
+				t.Errorf("got authorized %v, expected %v", authorized, test.authorized)
+			}
+		})
+	}
+
+	via := make([]*http.Request, MAX_REDIRECTS)
+	for i := range via {
+		via[i] = previous
+	}
+	if err := checkRedirect(previous, via); err == nil {
+		t.Error("expected error after too many redirects")
+	}
+}
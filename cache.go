@@ -0,0 +1,385 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	CACHE_ENTRY_DELIMITER = "@"
+	DAY_DURATION_SUFFIX   = "d"
+	CACHE_TIME_FORMAT     = "2006-01-02 15:04:05"
+)
+
+// Tool, whose archives are stored in cache.
+type cachedTool struct {
+	name   string
+	dir    string
+	prefix string
+}
+
+// All the tools, whose archives are stored in cache.
+// Directories are relative to cache root.
+var cachedTools = []cachedTool{
+	{name: PROTOC_EXECUTABLE, dir: "", prefix: PROTOC_CACHE_PREFIX},
+	{name: FLATC_EXECUTABLE, dir: "", prefix: FLATC_CACHE_PREFIX},
+	{name: GOOGLEAPIS_CACHE_DIR, dir: GOOGLEAPIS_CACHE_DIR, prefix: GOOGLEAPIS_DIR_PREFIX},
+}
+
+// Get all the tools, stored in cache: archives, GO plugins and prebuilt plugins.
+// Plugins are discovered from the plugins cache directory (so that plugins, removed from configuration, are still listed), configured plugins are added too.
+//
+// Accept cache root path and configuration pointer.
+// Return cached tools list.
+func getCachedTools(cacheDir string, config *Config) []cachedTool {
+	var plugins []string
+	for _, plugin := range getProtocPlugins(config) {
+		plugins = append(plugins, plugin.name)
+	}
+	for _, plugin := range config.Protoc.BinaryPlugins {
+		plugins = append(plugins, plugin.Name)
+	}
+
+	pluginsCache := filepath.Join(cacheDir, PLUGINS_CACHE_DIR)
+	dirEntries, err := os.ReadDir(pluginsCache)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		logrus.Debugf("Could not read plugins cache directory %s: %v", pluginsCache, err)
+	}
+	for _, dirEntry := range dirEntries {
+		if name, _, ok := strings.Cut(dirEntry.Name(), PLUGIN_CACHE_DELIMITER); ok && dirEntry.IsDir() && !strings.HasPrefix(name, ".") {
+			plugins = append(plugins, name)
+		}
+	}
+
+	slices.Sort(plugins)
+	tools := slices.Clone(cachedTools)
+	for _, plugin := range slices.Compact(plugins) {
+		tools = append(tools, cachedTool{name: plugin, dir: PLUGINS_CACHE_DIR, prefix: getPluginCachePrefix(plugin)})
+	}
+	return tools
+}
+
+// "protogo cache" subcommands.
+var cacheCommands = map[string]func(cacheDir string, config *Config, args []string) error{
+	"list":  cacheListCommand,
+	"prune": cachePruneCommand,
+	"rm":    cacheRemoveCommand,
+	"clean": cacheCleanCommand,
+}
+
+// Remove cache entry directory, holding its lock, so that it is not removed while being downloaded by another "protogo" run.
+//
+// Accept cache entry directory path.
+// Return error.
+func removeCacheEntry(entryDir string) error {
+	unlock, err := lockCacheEntry(entryDir)
+	if err != nil {
+		return err
+	} else {
+		defer unlock()
+	}
+
+	return os.RemoveAll(entryDir)
+}
+
+// Calculate total size of all the files in a directory.
+//
+// Accept directory path.
+// Return total size in bytes and error.
+func getDirectorySize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		} else if entry.Type().IsRegular() {
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+// Format size in bytes as a human-readable string (e.g. "12.3 MiB").
+//
+// Accept size in bytes.
+// Return formatted size.
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// Parse age duration, either in GO duration format (e.g. "12h") or in days (e.g. "30d").
+//
+// Accept age string.
+// Return age duration and error.
+func parseAge(age string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(age, DAY_DURATION_SUFFIX); ok {
+		number, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid number of days '%s': %v", days, err)
+		}
+		return time.Duration(number) * 24 * time.Hour, nil
+	}
+
+	return time.ParseDuration(age)
+}
+
+// Get names of all the tools, stored in cache.
+//
+// Accept cache root path and configuration pointer.
+// Return cached tool names list.
+func getCachedToolNames(cacheDir string, config *Config) []string {
+	var names []string
+	for _, tool := range getCachedTools(cacheDir, config) {
+		names = append(names, tool.name)
+	}
+	return names
+}
+
+// Find cached tool by name.
+//
+// Accept cache root path, configuration pointer and tool name.
+// Return cached tool pointer (or nil if not found).
+func findCachedTool(cacheDir string, config *Config, name string) *cachedTool {
+	for _, tool := range getCachedTools(cacheDir, config) {
+		if tool.name == name {
+			return &tool
+		}
+	}
+	return nil
+}
+
+// Collect all the archive and plugin versions, pinned in the project lock file.
+//
+// Accept configuration pointer.
+// Return set of "[TOOL]@[VERSION]" strings and error.
+func getLockedEntries(config *Config) (map[string]bool, error) {
+	lock, err := readLockFile(filepath.Join(config.ProjectDir, LOCK_FILE_NAME))
+	if err != nil {
+		return nil, err
+	}
+
+	infos := map[string]*ArchiveInfo{PROTOC_EXECUTABLE: lock.Protoc, FLATC_EXECUTABLE: lock.Flatc, GOOGLEAPIS_CACHE_DIR: lock.GoogleAPIs}
+	for name, info := range lock.BinaryPlugins {
+		infos[name] = info
+	}
+
+	locked := make(map[string]bool)
+	for name, info := range infos {
+		if info != nil {
+			locked[name+CACHE_ENTRY_DELIMITER+strings.TrimPrefix(info.Version, "v")] = true
+		}
+	}
+	for name, plugin := range lock.Plugins {
+		locked[name+CACHE_ENTRY_DELIMITER+strings.TrimPrefix(plugin.Version, "v")] = true
+	}
+	return locked, nil
+}
+
+// Run "protogo cache list" command.
+// Print all the cached archives with their sizes and last usage times.
+//
+// Accept cache root path, configuration pointer and command arguments (unused).
+// Return error.
+func cacheListCommand(cacheDir string, config *Config, _ []string) error {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "TOOL\tVERSION\tSIZE\tLAST USED\tPATH")
+
+	var total int64
+	for _, tool := range getCachedTools(cacheDir, config) {
+		entries, err := listCacheEntries(filepath.Join(cacheDir, tool.dir), tool.prefix)
+		if err != nil {
+			return fmt.Errorf("could not list %s cache: %v", tool.name, err)
+		}
+
+		for _, entry := range entries {
+			size, err := getDirectorySize(entry.path)
+			if err != nil {
+				return fmt.Errorf("could not calculate %s size: %v", entry.path, err)
+			}
+			total += size
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", tool.name, entry.version, formatSize(size), entry.used.Format(CACHE_TIME_FORMAT), entry.path)
+		}
+	}
+
+	fmt.Fprintf(writer, "TOTAL\t\t%s\t\t%s\n", formatSize(total), cacheDir)
+	return writer.Flush()
+}
+
+// Run "protogo cache prune" command.
+// Remove cached archives, that are either not among N most recently used versions of each tool ("--keep=N"),
+// or were not used for the given time ("--older-than=30d"), or both (if both flags are specified).
+// Archives pinned in the project lock file are never removed.
+//
+// Accept cache root path, configuration pointer and command arguments.
+// Return error.
+func cachePruneCommand(cacheDir string, config *Config, args []string) error {
+	flags := flag.NewFlagSet("prune", flag.ContinueOnError)
+	keep := flags.Int("keep", -1, "number of most recently used versions of each tool to keep")
+	olderThan := flags.String("older-than", "", "remove versions not used for this time (e.g. '72h' or '30d')")
+	err := flags.Parse(args)
+	if err != nil {
+		return fmt.Errorf("could not parse arguments: %v", err)
+	} else if *keep < 0 && *olderThan == "" {
+		return fmt.Errorf("either '--keep' or '--older-than' flag should be specified")
+	}
+
+	var threshold time.Time
+	if *olderThan != "" {
+		age, err := parseAge(*olderThan)
+		if err != nil {
+			return fmt.Errorf("could not parse age '%s': %v", *olderThan, err)
+		}
+		threshold = time.Now().Add(-age)
+	}
+
+	locked, err := getLockedEntries(config)
+	if err != nil {
+		return fmt.Errorf("could not read lock file: %v", err)
+	}
+
+	for _, tool := range getCachedTools(cacheDir, config) {
+		entries, err := listCacheEntries(filepath.Join(cacheDir, tool.dir), tool.prefix)
+		if err != nil {
+			return fmt.Errorf("could not list %s cache: %v", tool.name, err)
+		}
+
+		slices.SortFunc(entries, func(a, b cacheEntry) int {
+			return b.used.Compare(a.used)
+		})
+
+		for i, entry := range entries {
+			name := tool.name + CACHE_ENTRY_DELIMITER + entry.version
+			if locked[name] {
+				logrus.Debugf("Cache entry %s is locked, skipping", name)
+				continue
+			} else if *keep >= 0 && i < *keep {
+				continue
+			} else if *olderThan != "" && entry.used.After(threshold) {
+				continue
+			}
+
+			err = removeCacheEntry(entry.path)
+			if err != nil {
+				return fmt.Errorf("could not remove %s: %v", entry.path, err)
+			}
+			fmt.Printf("Removed %s: %s\n", name, entry.path)
+		}
+	}
+
+	return nil
+}
+
+// Run "protogo cache rm" command.
+// Remove the given cached archives, specified as "[TOOL]@[VERSION]" (e.g. "protoc@25.1").
+//
+// Accept cache root path, configuration pointer and command arguments.
+// Return error.
+func cacheRemoveCommand(cacheDir string, config *Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no cache entries to remove specified (use e.g. 'protogo cache rm %s%s25.1')", PROTOC_EXECUTABLE, CACHE_ENTRY_DELIMITER)
+	}
+
+	for _, arg := range args {
+		name, version, ok := strings.Cut(arg, CACHE_ENTRY_DELIMITER)
+		tool := findCachedTool(cacheDir, config, name)
+		if !ok || tool == nil {
+			return fmt.Errorf("invalid cache entry '%s', should be '[TOOL]%s[VERSION]', where tool is one of %s", arg, CACHE_ENTRY_DELIMITER, strings.Join(getCachedToolNames(cacheDir, config), ", "))
+		}
+
+		entryPath := filepath.Join(cacheDir, tool.dir, tool.prefix+strings.TrimPrefix(version, "v"))
+		if _, err := os.Stat(entryPath); err != nil {
+			return fmt.Errorf("cache entry %s not found in %s", arg, entryPath)
+		}
+
+		err := removeCacheEntry(entryPath)
+		if err != nil {
+			return fmt.Errorf("could not remove %s: %v", entryPath, err)
+		}
+		fmt.Printf("Removed %s: %s\n", arg, entryPath)
+	}
+
+	return nil
+}
+
+// Run "protogo cache clean" command.
+// Remove all the cached archives and plugins, as well as the resolved "latest" versions.
+// Every cache entry is removed holding its lock, so that entries being installed by concurrent "protogo" runs are not broken.
+// Lock files are never removed, since they can be held by concurrent "protogo" runs.
+//
+// Accept cache root path, configuration pointer and command arguments (unused).
+// Return error.
+func cacheCleanCommand(cacheDir string, config *Config, _ []string) error {
+	for _, tool := range getCachedTools(cacheDir, config) {
+		entries, err := listCacheEntries(filepath.Join(cacheDir, tool.dir), tool.prefix)
+		if err != nil {
+			return fmt.Errorf("could not list %s cache: %v", tool.name, err)
+		}
+
+		for _, entry := range entries {
+			err = removeCacheEntry(entry.path)
+			if err != nil {
+				return fmt.Errorf("could not remove %s: %v", entry.path, err)
+			}
+			fmt.Printf("Removed %s\n", entry.path)
+		}
+	}
+
+	latestPath := filepath.Join(cacheDir, LATEST_CACHE_FILE_NAME)
+	if _, err := os.Stat(latestPath); err == nil {
+		err = removeCacheEntry(latestPath)
+		if err != nil {
+			return fmt.Errorf("could not remove %s: %v", latestPath, err)
+		}
+		fmt.Printf("Removed %s\n", latestPath)
+	}
+
+	return nil
+}
+
+// Run "protogo cache" command.
+// Dispatch to one of the subcommands: "list", "prune", "rm" or "clean".
+//
+// Accept configuration pointer and command arguments (subcommand name and its arguments).
+// Return error.
+func cacheCommand(config *Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no cache subcommand specified (use one of: list, prune, rm, clean)")
+	}
+
+	command, ok := cacheCommands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown cache subcommand: %s (use one of: list, prune, rm, clean)", args[0])
+	}
+
+	cacheDir, err := getProtogoCacheDir(config.Cache)
+	if err != nil {
+		return fmt.Errorf("could not find or create cache directory: %v", err)
+	}
+
+	return command(*cacheDir, config, args[1:])
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/sirupsen/logrus"
+)
+
+const INTERRUPTED_EXIT_CODE = 130
+
+// Temporary paths (files and directories), that should be removed if "protogo" is interrupted.
+var cleanupPaths = make(map[string]bool)
+
+// Mutex, guarding temporary paths set.
+var cleanupMutex sync.Mutex
+
+// Register a temporary path (file or directory) for removal.
+// The path is removed either by the returned function (that should be deferred by the caller, so that it is called on panic as well)
+// or by the interruption handler (see "handleInterruptions"), if "protogo" is interrupted by a signal.
+//
+// Accept temporary path.
+// Return function, removing the path.
+func registerCleanup(path string) func() {
+	cleanupMutex.Lock()
+	cleanupPaths[path] = true
+	cleanupMutex.Unlock()
+
+	return func() {
+		cleanupMutex.Lock()
+		defer cleanupMutex.Unlock()
+
+		delete(cleanupPaths, path)
+		err := os.RemoveAll(path)
+		if err != nil {
+			logrus.Debugf("Could not remove temporary path %s: %v", path, err)
+		}
+	}
+}
+
+// Install interruption signal handler (for SIGINT and SIGTERM).
+// On interruption, all the registered temporary paths are removed and "protogo" exits with code 130.
+// Mutex is never released after that, so that no other temporary paths can be registered or removed concurrently.
+func handleInterruptions() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		received := <-signals
+		logrus.Warnf("Interrupted by signal %v, removing temporary files...", received)
+
+		cleanupMutex.Lock()
+		for path := range cleanupPaths {
+			err := os.RemoveAll(path)
+			if err != nil {
+				logrus.Warnf("Could not remove temporary path %s: %v", path, err)
+			}
+		}
+
+		os.Exit(INTERRUPTED_EXIT_CODE)
+	}()
+}
@@ -0,0 +1,406 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	CONFIG_FILE_NAME  = "protogo.yaml"
+	CONFIG_DIR_NAME   = "protogo"
+	GO_MOD_FILE_NAME  = "go.mod"
+	FLAG_PREFIX       = "--"
+	FLAG_VALUE_DELIM  = "="
+	INCLUDE_DELIMITER = ","
+)
+
+// GO plugin configuration.
+// Package is the full GO package path of the plugin command (e.g. "connectrpc.com/connect/cmd/protoc-gen-connect-go"),
+// it is required for all the plugins except for built-in ones, name defaults to the last package path element.
+// Empty version means it is derived from the current GO module requirements (for built-in plugins) or from lock file, "latest" otherwise.
+type PluginConfig struct {
+	Name    string `yaml:"name"`
+	Package string `yaml:"package"`
+	Version string `yaml:"version"`
+}
+
+// Prebuilt plugin configuration, the plugin is downloaded from GitHub releases (or a mirror) of the given repository.
+// Assets map platforms ("[GOOS]/[GOARCH]", e.g. "linux/amd64") to release asset names, tag is the release tag,
+// binary is the plugin executable path inside the asset archive (ignored for non-archive assets), default: plugin name.
+// Assets, tag and binary are templates, "{{.Version}}" is replaced with the plugin version, tag default is "v{{.Version}}".
+type BinaryPluginConfig struct {
+	Name       string            `yaml:"name"`
+	Repository string            `yaml:"repository"`
+	Mirror     string            `yaml:"mirror"`
+	Version    string            `yaml:"version"`
+	Tag        string            `yaml:"tag"`
+	Assets     map[string]string `yaml:"assets"`
+	Binary     string            `yaml:"binary"`
+}
+
+// Protobuf compiler configuration.
+// Prebuilt plugins flag enables downloading prebuilt release assets of GO plugins (if available) instead of installing them with "go install".
+type ProtocConfig struct {
+	Version         string               `yaml:"version"`
+	Include         []string             `yaml:"include"`
+	Mirror          string               `yaml:"mirror"`
+	Plugins         []PluginConfig       `yaml:"plugins"`
+	BinaryPlugins   []BinaryPluginConfig `yaml:"binary_plugins"`
+	PrebuiltPlugins bool                 `yaml:"prebuilt_plugins"`
+}
+
+// Flatbuffers compiler configuration.
+type FlatcConfig struct {
+	Version string `yaml:"version"`
+	Distro  string `yaml:"distro"`
+	Mirror  string `yaml:"mirror"`
+}
+
+// Google APIs library configuration.
+type GoogleAPIsConfig struct {
+	Mirror string `yaml:"mirror"`
+}
+
+// Network configuration.
+// Connect timeout limits connection establishment (including TLS handshake), total timeout limits the whole request (including response reading).
+// Rate limit wait is the maximum time to wait for GitHub API rate limit reset.
+// Credentials map hosts (with or without port) to bearer tokens, that are sent to these hosts only.
+type NetworkConfig struct {
+	ConnectTimeout time.Duration     `yaml:"connect_timeout"`
+	Timeout        time.Duration     `yaml:"timeout"`
+	Retries        int               `yaml:"retries"`
+	RateLimitWait  time.Duration     `yaml:"rate_limit_wait"`
+	Credentials    map[string]string `yaml:"credentials"`
+}
+
+// Complete "protogo" configuration.
+// Is assembled from user config file, project config file, environment variables and command line flags (in the order of increasing precedence).
+type Config struct {
+	GoExecutable     string            `yaml:"go_executable"`
+	Cache            string            `yaml:"cache"`
+	LogLevel         string            `yaml:"log_level"`
+	Offline          bool              `yaml:"offline"`
+	LatestTTL        time.Duration     `yaml:"latest_ttl"`
+	Refresh          bool              `yaml:"-"`
+	Network          NetworkConfig     `yaml:"network"`
+	Protoc           ProtocConfig      `yaml:"protoc"`
+	Flatc            FlatcConfig       `yaml:"flatc"`
+	GoogleAPIs       GoogleAPIsConfig  `yaml:"googleapis"`
+	Checksums        map[string]string `yaml:"checksums"`
+	RequireChecksums bool              `yaml:"require_checksums"`
+	ProjectDir       string            `yaml:"-"`
+}
+
+// Configuration option, that can be set by an environment variable and by a command line flag.
+// Boolean options can be set by a flag without value.
+type configOption struct {
+	env     string
+	flag    string
+	boolean bool
+	apply   func(config *Config, value string) error
+}
+
+// All the configuration options that can be set by environment variables and command line flags.
+var configOptions = []configOption{
+	{env: "PROTOGO_GO_EXECUTABLE", flag: "go-executable", apply: func(config *Config, value string) error {
+		config.GoExecutable = value
+		return nil
+	}},
+	{env: "PROTOGO_CACHE", flag: "cache", apply: func(config *Config, value string) error {
+		config.Cache = value
+		return nil
+	}},
+	{env: "PROTOGO_LOG_LEVEL", flag: "log-level", apply: func(config *Config, value string) error {
+		config.LogLevel = value
+		return nil
+	}},
+	{env: "PROTOGO_OFFLINE", flag: "offline", boolean: true, apply: func(config *Config, value string) error {
+		offline, err := strconv.ParseBool(value)
+		config.Offline = offline
+		return err
+	}},
+	{env: "PROTOGO_REQUIRE_CHECKSUMS", flag: "require-checksums", boolean: true, apply: func(config *Config, value string) error {
+		required, err := strconv.ParseBool(value)
+		config.RequireChecksums = required
+		return err
+	}},
+	{env: "PROTOGO_LATEST_TTL", flag: "latest-ttl", apply: func(config *Config, value string) error {
+		ttl, err := time.ParseDuration(value)
+		config.LatestTTL = ttl
+		return err
+	}},
+	{env: "PROTOGO_REFRESH", flag: "refresh", boolean: true, apply: func(config *Config, value string) error {
+		refresh, err := strconv.ParseBool(value)
+		config.Refresh = refresh
+		return err
+	}},
+	{env: "PROTOGO_CONNECT_TIMEOUT", flag: "connect-timeout", apply: func(config *Config, value string) error {
+		timeout, err := time.ParseDuration(value)
+		config.Network.ConnectTimeout = timeout
+		return err
+	}},
+	{env: "PROTOGO_TIMEOUT", flag: "timeout", apply: func(config *Config, value string) error {
+		timeout, err := time.ParseDuration(value)
+		config.Network.Timeout = timeout
+		return err
+	}},
+	{env: "PROTOGO_RETRIES", flag: "retries", apply: func(config *Config, value string) error {
+		retries, err := strconv.Atoi(value)
+		config.Network.Retries = retries
+		return err
+	}},
+	{env: "PROTOGO_RATE_LIMIT_WAIT", flag: "rate-limit-wait", apply: func(config *Config, value string) error {
+		wait, err := time.ParseDuration(value)
+		config.Network.RateLimitWait = wait
+		return err
+	}},
+	{env: "PROTOGO_PROTOC_VERSION", flag: "protoc-version", apply: func(config *Config, value string) error {
+		config.Protoc.Version = value
+		return nil
+	}},
+	{env: "PROTOGO_PROTOC_INCLUDE", flag: "protoc-include", apply: func(config *Config, value string) error {
+		config.Protoc.Include = strings.Split(value, INCLUDE_DELIMITER)
+		return nil
+	}},
+	{env: "PROTOGO_PROTOC_PREBUILT_PLUGINS", flag: "protoc-prebuilt-plugins", boolean: true, apply: func(config *Config, value string) error {
+		prebuilt, err := strconv.ParseBool(value)
+		config.Protoc.PrebuiltPlugins = prebuilt
+		return err
+	}},
+	{env: "PROTOGO_PROTOC_GEN_GO_VERSION", flag: "protoc-gen-go-version", apply: func(config *Config, value string) error {
+		setPluginVersion(config, PROTOC_GEN_GO_PACKAGE, value)
+		return nil
+	}},
+	{env: "PROTOGO_PROTOC_GEN_GO_GRPC_VERSION", flag: "protoc-gen-go-grpc-version", apply: func(config *Config, value string) error {
+		setPluginVersion(config, PROTOC_GEN_GO_GRPC_PACKAGE, value)
+		return nil
+	}},
+	{env: "PROTOGO_FLATC_VERSION", flag: "flatc-version", apply: func(config *Config, value string) error {
+		config.Flatc.Version = value
+		return nil
+	}},
+	{env: "PROTOGO_FLATC_DISTRO", flag: "flatc-distro", apply: func(config *Config, value string) error {
+		config.Flatc.Distro = value
+		return nil
+	}},
+	{env: "PROTOGO_PROTOC_MIRROR", flag: "protoc-mirror", apply: func(config *Config, value string) error {
+		config.Protoc.Mirror = value
+		return nil
+	}},
+	{env: "PROTOGO_FLATC_MIRROR", flag: "flatc-mirror", apply: func(config *Config, value string) error {
+		config.Flatc.Mirror = value
+		return nil
+	}},
+	{env: "PROTOGO_GOOGLEAPIS_MIRROR", flag: "googleapis-mirror", apply: func(config *Config, value string) error {
+		config.GoogleAPIs.Mirror = value
+		return nil
+	}},
+}
+
+// Create configuration with all the default values.
+//
+// Return default configuration pointer.
+func defaultConfig() *Config {
+	return &Config{
+		GoExecutable: getExecutableName(GO_EXECUTABLE),
+		LogLevel:     "WARN",
+		LatestTTL:    DEFAULT_LATEST_TTL,
+		Network:      NetworkConfig{ConnectTimeout: DEFAULT_CONNECT_TIMEOUT, Timeout: DEFAULT_REQUEST_TIMEOUT, Retries: DEFAULT_REQUEST_RETRIES},
+		Protoc:       ProtocConfig{Version: "latest", PrebuiltPlugins: true},
+		Flatc:        FlatcConfig{Version: "latest"},
+	}
+}
+
+// Find user config file, located in [user config directory].
+//
+// Return config file path and boolean flag, whether the file exists.
+//
+// [user config directory]: https://pkg.go.dev/os#UserConfigDir
+func findUserConfigFile() (string, bool) {
+	userConfig, err := os.UserConfigDir()
+	if err != nil {
+		return "", false
+	}
+
+	configFile := filepath.Join(userConfig, CONFIG_DIR_NAME, CONFIG_FILE_NAME)
+	_, err = os.Stat(configFile)
+	return configFile, err == nil
+}
+
+// Find project directory.
+// Walk up from the given directory until either config file or GO module root (directory containing "go.mod") is found.
+// If none of them is found, the given directory is the project directory.
+//
+// Accept directory to start search from.
+// Return project directory path.
+func findProjectDir(dir string) string {
+	for current := dir; ; {
+		if _, err := os.Stat(filepath.Join(current, CONFIG_FILE_NAME)); err == nil {
+			return current
+		}
+
+		if _, err := os.Stat(filepath.Join(current, GO_MOD_FILE_NAME)); err == nil {
+			return current
+		}
+
+		parent := filepath.Dir(current)
+		if parent == current {
+			return dir
+		}
+		current = parent
+	}
+}
+
+// Read YAML config file and apply its values on top of the given configuration.
+// Only the values present in the file are overwritten.
+// Relative cache path is resolved relatively to the config file directory.
+// Network credentials are ignored (with a warning) in project config files, since a checked-in file should never decide, where user tokens are sent.
+//
+// Accept configuration pointer, config file path and boolean flag, whether it is a project config file.
+// Return error.
+func applyConfigFile(config *Config, path string, project bool) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading config file %s: %v", path, err)
+	}
+
+	previousCache := config.Cache
+	previousCredentials := maps.Clone(config.Network.Credentials)
+	err = yaml.Unmarshal(data, config)
+	if err != nil {
+		return fmt.Errorf("error parsing config file %s: %v", path, err)
+	}
+
+	if config.Cache != previousCache && !filepath.IsAbs(config.Cache) {
+		config.Cache = filepath.Join(filepath.Dir(path), config.Cache)
+	}
+
+	if project && !maps.Equal(config.Network.Credentials, previousCredentials) {
+		logrus.Warnf("Network credentials in project config file %s are ignored (set them in user config file instead)", path)
+		config.Network.Credentials = previousCredentials
+	}
+
+	return nil
+}
+
+// Apply values of all the set "PROTOGO_..." environment variables on top of the given configuration.
+//
+// Accept configuration pointer.
+// Return error.
+func applyConfigEnvironment(config *Config) error {
+	for _, option := range configOptions {
+		if value, ok := os.LookupEnv(option.env); ok {
+			logrus.Debugf("Configuration environment variable found: %s", option.env)
+			err := option.apply(config, value)
+			if err != nil {
+				return fmt.Errorf("error applying environment variable %s: %v", option.env, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Apply leading "protogo" command line flags on top of the given configuration.
+// Flags are either "--name=value" or "--name" (for boolean options) and should precede all the other arguments.
+// Parsing stops at the first argument that is not a known flag.
+//
+// Accept configuration pointer and command line arguments (without executable name).
+// Return the remaining arguments and error.
+func applyConfigFlags(config *Config, args []string) ([]string, error) {
+	for len(args) > 0 && args[0] != FLAG_PREFIX && strings.HasPrefix(args[0], FLAG_PREFIX) {
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(args[0], FLAG_PREFIX), FLAG_VALUE_DELIM)
+
+		index := -1
+		for i, option := range configOptions {
+			if option.flag == name {
+				index = i
+				break
+			}
+		}
+		if index == -1 {
+			break
+		}
+
+		option := configOptions[index]
+		if !hasValue {
+			if !option.boolean {
+				return nil, fmt.Errorf("flag %s%s requires a value", FLAG_PREFIX, name)
+			}
+			value = "true"
+		}
+
+		logrus.Debugf("Configuration flag found: %s", name)
+		err := option.apply(config, value)
+		if err != nil {
+			return nil, fmt.Errorf("error applying flag %s%s: %v", FLAG_PREFIX, name, err)
+		}
+		args = args[1:]
+	}
+
+	return args, nil
+}
+
+// Load "protogo" configuration.
+// Start with default values, then apply user config file, project config file, environment variables and command line flags.
+// Project directory (containing project config file and lock file) is searched starting from the current working directory.
+//
+// Accept command line arguments (without executable name).
+// Return configuration pointer, the remaining command line arguments and error.
+func loadConfig(args []string) (*Config, []string, error) {
+	config := defaultConfig()
+
+	if userConfigFile, ok := findUserConfigFile(); ok {
+		logrus.Debugf("Applying user config file: %s", userConfigFile)
+		err := applyConfigFile(config, userConfigFile, false)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error loading user config: %v", err)
+		}
+	}
+
+	workingDir, err := os.Getwd()
+	if err != nil {
+		return nil, nil, errors.New("current working directory couldn't be resolved")
+	}
+
+	config.ProjectDir = findProjectDir(workingDir)
+	projectConfigFile := filepath.Join(config.ProjectDir, CONFIG_FILE_NAME)
+	if _, err := os.Stat(projectConfigFile); err == nil {
+		logrus.Debugf("Applying project config file: %s", projectConfigFile)
+		err := applyConfigFile(config, projectConfigFile, true)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error loading project config: %v", err)
+		}
+	}
+
+	err = applyConfigEnvironment(config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error loading environment config: %v", err)
+	}
+
+	args, err = applyConfigFlags(config, args)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error loading command line config: %v", err)
+	}
+
+	err = validatePluginConfigs(config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error validating plugins config: %v", err)
+	}
+
+	err = validateBinaryPluginConfigs(config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error validating prebuilt plugins config: %v", err)
+	}
+
+	return config, args, nil
+}
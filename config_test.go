@@ -0,0 +1,179 @@
+package main
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestApplyConfigFlags(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		remaining []string
+		check     func(config *Config) bool
+		fails     bool
+	}{
+		{
+			name:      "value flag",
+			args:      []string{"--protoc-version=25.1", "version", "--", "protoc"},
+			remaining: []string{"version", "--", "protoc"},
+			check:     func(config *Config) bool { return config.Protoc.Version == "25.1" },
+		},
+		{
+			name:      "boolean flag without value",
+			args:      []string{"--offline", "--", "protoc"},
+			remaining: []string{"--", "protoc"},
+			check:     func(config *Config) bool { return config.Offline },
+		},
+		{
+			name:      "boolean flag with value",
+			args:      []string{"--protoc-prebuilt-plugins=false", "lock"},
+			remaining: []string{"lock"},
+			check:     func(config *Config) bool { return !config.Protoc.PrebuiltPlugins },
+		},
+		{
+			name:      "log level flag",
+			args:      []string{"--log-level=DEBUG", "cache", "list"},
+			remaining: []string{"cache", "list"},
+			check:     func(config *Config) bool { return config.LogLevel == "DEBUG" },
+		},
+		{
+			name:      "duration flag",
+			args:      []string{"--timeout=5m"},
+			remaining: []string{},
+			check:     func(config *Config) bool { return config.Network.Timeout == 5*time.Minute },
+		},
+		{
+			name:      "parsing stops at unknown flag",
+			args:      []string{"--cache=/tmp/protogo", "--mod=vendor", "--flatc-version=24.3.25"},
+			remaining: []string{"--mod=vendor", "--flatc-version=24.3.25"},
+			check:     func(config *Config) bool { return config.Cache == "/tmp/protogo" && config.Flatc.Version == "latest" },
+		},
+		{
+			name:      "parsing stops at delimiter",
+			args:      []string{"--", "--offline"},
+			remaining: []string{"--", "--offline"},
+			check:     func(config *Config) bool { return !config.Offline },
+		},
+		{
+			name:  "value flag without value",
+			args:  []string{"--protoc-version"},
+			fails: true,
+		},
+		{
+			name:  "invalid value",
+			args:  []string{"--retries=many"},
+			fails: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := defaultConfig()
+			remaining, err := applyConfigFlags(config, test.args)
+			if test.fails {
+				if err == nil {
+					t.Fatalf("expected error, got remaining arguments %v", remaining)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !slices.Equal(remaining, test.remaining) {
+				t.Errorf("remaining arguments %v, expected %v", remaining, test.remaining)
+			}
+			if !test.check(config) {
+				t.Errorf("configuration was not applied: %+v", config)
+			}
+		})
+	}
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	userConfig := filepath.Join(dir, "user.yaml")
+	projectConfig := filepath.Join(dir, "project.yaml")
+
+	err := os.WriteFile(userConfig, []byte("protoc:\n  version: 21.0\nflatc:\n  version: 22.0\nnetwork:\n  retries: 1\nlog_level: INFO\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(projectConfig, []byte("protoc:\n  version: 23.0\nflatc:\n  version: 24.0\ncache: .protogo\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("PROTOGO_FLATC_VERSION", "25.0")
+	t.Setenv("PROTOGO_RETRIES", "5")
+
+	config := defaultConfig()
+	for _, file := range []string{userConfig, projectConfig} {
+		err = applyConfigFile(config, file, file == projectConfig)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = applyConfigEnvironment(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = applyConfigFlags(config, []string{"--retries=7"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		actual   any
+		expected any
+	}{
+		{name: "user config over default", actual: config.LogLevel, expected: "INFO"},
+		{name: "project config over user config", actual: config.Protoc.Version, expected: "23.0"},
+		{name: "environment over project config", actual: config.Flatc.Version, expected: "25.0"},
+		{name: "flag over environment", actual: config.Network.Retries, expected: 7},
+		{name: "default kept", actual: config.Network.Timeout, expected: DEFAULT_REQUEST_TIMEOUT},
+		{name: "relative cache resolved from config file", actual: config.Cache, expected: filepath.Join(dir, ".protogo")},
+	}
+
+	for _, test := range tests {
+		if test.actual != test.expected {
+			t.Errorf("%s: got %v, expected %v", test.name, test.actual, test.expected)
+		}
+	}
+}
+
+func TestProjectConfigCredentials(t *testing.T) {
+	dir := t.TempDir()
+	userConfig := filepath.Join(dir, "user.yaml")
+	projectConfig := filepath.Join(dir, "project.yaml")
+
+	err := os.WriteFile(userConfig, []byte("network:\n  credentials:\n    mirror.example.com: ${MIRROR_TOKEN}\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(projectConfig, []byte("network:\n  retries: 5\n  credentials:\n    evil.example.com: ${GITHUB_TOKEN}\n    mirror.example.com: ${GITHUB_TOKEN}\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := defaultConfig()
+	if err = applyConfigFile(config, userConfig, false); err != nil {
+		t.Fatal(err)
+	}
+	if err = applyConfigFile(config, projectConfig, true); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{"mirror.example.com": "${MIRROR_TOKEN}"}
+	if !maps.Equal(config.Network.Credentials, expected) {
+		t.Errorf("got credentials %v, expected %v", config.Network.Credentials, expected)
+	}
+	if config.Network.Retries != 5 {
+		t.Errorf("project config was not applied: %+v", config.Network)
+	}
+}
@@ -1,13 +1,17 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
@@ -17,8 +21,42 @@ const (
 	NONE_EXECUTABLE   = ""
 	PROTOC_EXECUTABLE = "protoc"
 	FLATC_EXECUTABLE  = "flatc"
+
+	PROTOC_CACHE_PREFIX  = "protoc-"
+	FLATC_CACHE_PREFIX   = "flatc-"
+	GOOGLEAPIS_CACHE_DIR = "googleapis"
+
+	CACHE_USAGE_FILE_NAME    = ".protogo-used"
+	CACHE_COMPLETE_FILE_NAME = ".protogo-complete"
+	CACHE_STAGING_INFIX      = ".staging-"
+
+	LATEST_CACHE_FILE_NAME = "latest.json"
+	DEFAULT_LATEST_TTL     = 24 * time.Hour
 )
 
+// Time, during which resolved "latest" versions are reused without network requests (zero disables caching).
+var latestTTL = DEFAULT_LATEST_TTL
+
+// Refresh flag, "latest" versions are always resolved from network if it is set (and the cached ones are updated).
+var latestRefresh = false
+
+// Resolved "latest" version, stored in cache.
+// Source is the URL the version was resolved from, so that the version is not reused if e.g. mirror changes.
+type latestVersion struct {
+	Version  string    `json:"version"`
+	Source   string    `json:"source"`
+	Resolved time.Time `json:"resolved"`
+}
+
+// Installed archive, found in cache.
+// Last usage time is equal to installation time if the archive was never used.
+type cacheEntry struct {
+	version   string
+	path      string
+	installed time.Time
+	used      time.Time
+}
+
 // Get GO environmental variable by running "go env ..." command.
 // Return empty string if not found.
 //
@@ -34,32 +72,7 @@ func lookupGoEnv(goExecuteble, key string) (string, bool) {
 	return strings.TrimSuffix(string(output), "\n"), true
 }
 
-// Find GO executable, either locally or by provided path.
-// Verify the executable exists.
-//
-// Accept custom GO executable path envieonment variable (or empty string if none).
-// Return verified GO executable pointer and error.
-func getGoExecutable(key string) (*string, error) {
-	var executable string
-
-	if value, ok := os.LookupEnv(key); ok {
-		executable = value
-	} else {
-		executable = getExecutableName(GO_EXECUTABLE)
-	}
-
-	logrus.Debugf("Looking up for GO executable: %s", executable)
-	_, err := exec.LookPath(executable)
-	if err != nil {
-		return nil, fmt.Errorf("go executable couldn't be found: %v", err)
-	} else {
-		logrus.Debug("GO executable found!")
-	}
-
-	return &executable, nil
-}
-
-// Find GO binary directory location.
+// Get GO binary location, where "go install ..." places executables.
 // Just like "go install ..." [documentation] suggests, all possible binary locations are searched.
 //
 // Accept GO executable path.
@@ -84,20 +97,33 @@ func getGoBinaryLocation(goExecuteble string) (*string, error) {
 	return &binary, nil
 }
 
+// Find GO executable, either locally or by provided path.
+// Verify the executable exists.
+//
+// Accept GO executable name or path.
+// Return verified GO executable pointer and error.
+func getGoExecutable(executable string) (*string, error) {
+	logrus.Debugf("Looking up for GO executable: %s", executable)
+	_, err := exec.LookPath(executable)
+	if err != nil {
+		return nil, fmt.Errorf("go executable couldn't be found: %v", err)
+	} else {
+		logrus.Debug("GO executable found!")
+	}
+
+	return &executable, nil
+}
+
 // Get "protogo" package cache directory.
-// Is either specified by environmental variable or placed into [default cache directory].
+// Is either specified by configuration or placed into [default cache directory].
 // Create the directory if it doesn't exist.
 //
-// Accept custom cache directory environment variable (or empty string if none).
+// Accept custom cache directory (or empty string if none).
 // Return cache directory path pointer and error.
 //
 // [default cache directory]: https://pkg.go.dev/os#UserCacheDir
-func getProtogoCacheDir(key string) (*string, error) {
-	var cacheDir string
-
-	if value, ok := os.LookupEnv(key); ok {
-		cacheDir = value
-	} else {
+func getProtogoCacheDir(cacheDir string) (*string, error) {
+	if cacheDir == "" {
 		userCache, err := os.UserCacheDir()
 		if err != nil {
 			return nil, errors.New("user cache directory couldn't be resolved")
@@ -116,30 +142,370 @@ func getProtogoCacheDir(key string) (*string, error) {
 	return &cacheDir, nil
 }
 
-// Get cached protobuf compiler by version.
-// Resolve requested protobuf version, find out the exact version name for "latest".
-// Verify "protoc" is installed locally, if "local" is specified as version.
-// Search for the required version directory in cache otherwise.
+// List all the installed archives in a cache directory.
+// Installed archives are subdirectories, named "[PREFIX][VERSION]" and containing installation completion marker file.
 //
-// Accept protobuf compiler version environment variable (with or without "v" prefix, empty string if none) and cache root path.
-// Return version tag string pointer, cache directory for the given version (or nil for "local"), boolean flag, whether protoc binary should be downloaded, and error.
-func getProtocCache(key, cacheDir string) (*string, *string, bool, error) {
-	var versionTag string
+// Accept cache directory path and archive directory name prefix.
+// Return cache entries list, sorted by version in descending order, and error.
+func listCacheEntries(cacheDir, prefix string) ([]cacheEntry, error) {
+	dirEntries, err := os.ReadDir(cacheDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("error reading cache directory %s: %v", cacheDir, err)
+	}
+
+	var entries []cacheEntry
+	for _, dirEntry := range dirEntries {
+		if !dirEntry.IsDir() || !strings.HasPrefix(dirEntry.Name(), prefix) {
+			continue
+		}
+
+		entryPath := filepath.Join(cacheDir, dirEntry.Name())
+		info, err := os.Stat(filepath.Join(entryPath, CACHE_COMPLETE_FILE_NAME))
+		if err != nil {
+			continue
+		}
+
+		entry := cacheEntry{version: strings.TrimPrefix(dirEntry.Name(), prefix), path: entryPath, installed: info.ModTime(), used: info.ModTime()}
+		if usage, err := os.Stat(filepath.Join(entryPath, CACHE_USAGE_FILE_NAME)); err == nil {
+			entry.used = usage.ModTime()
+		}
+		entries = append(entries, entry)
+	}
+
+	slices.SortFunc(entries, func(a, b cacheEntry) int {
+		return compareVersions(b.version, a.version)
+	})
+	return entries, nil
+}
+
+// Mark installed archive as used now, updating its usage marker file modification time.
+// Errors are ignored, as usage time is informational only.
+//
+// Accept installed archive directory path.
+func markCacheEntryUsed(dir string) {
+	marker := filepath.Join(dir, CACHE_USAGE_FILE_NAME)
+	now := time.Now()
+
+	err := os.Chtimes(marker, now, now)
+	if errors.Is(err, os.ErrNotExist) {
+		err = os.WriteFile(marker, nil, 0644)
+	}
+	if err != nil {
+		logrus.Debugf("Could not mark cache entry %s as used: %v", dir, err)
+	}
+}
+
+// Check whether a cache entry is complete.
+// Complete cache entry directory should contain installation completion marker file and all the given files.
+//
+// Accept cache entry directory path and required file paths (relative to it).
+// Return boolean flag, whether the entry is complete.
+func isCacheEntryComplete(dir string, files ...string) bool {
+	for _, file := range append(files, CACHE_COMPLETE_FILE_NAME) {
+		if _, err := os.Stat(filepath.Join(dir, file)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Check whether an archive is installed in a cache directory.
+// Installed archive directory should be a complete cache entry, containing archive info file and all the given files.
+//
+// Accept installed archive directory path and required file paths (relative to it).
+// Return boolean flag, whether the archive is installed.
+func isArchiveInstalled(dir string, files ...string) bool {
+	return isCacheEntryComplete(dir, append(files, ARCHIVE_INFO_FILE_NAME)...)
+}
+
+// Commit staging directory as a cache entry.
+// Write installation completion marker file to the staging directory, remove incomplete entry (if any) and rename the staging directory into the entry directory.
+// Entry directory is expected to be locked by the caller.
+//
+// Accept staging directory path and cache entry directory path.
+// Return error.
+func commitCacheEntry(stagingDir, entryDir string) error {
+	err := os.WriteFile(filepath.Join(stagingDir, CACHE_COMPLETE_FILE_NAME), nil, 0644)
+	if err != nil {
+		return fmt.Errorf("error writing installation completion marker to %s: %v", stagingDir, err)
+	}
+
+	logrus.Debugf("Replacing cache entry: %s", entryDir)
+	err = os.RemoveAll(entryDir)
+	if err != nil {
+		return fmt.Errorf("error removing incomplete cache entry %s: %v", entryDir, err)
+	}
+
+	err = os.Rename(stagingDir, entryDir)
+	if err != nil {
+		return fmt.Errorf("error moving staging directory %s to %s: %v", stagingDir, entryDir, err)
+	}
+
+	return nil
+}
+
+// Get latest installed archive version in a cache directory.
+// Used for "latest" version resolution in offline mode.
+//
+// Accept tool name (for error message), cache directory path and archive directory name prefix.
+// Return latest cached version string pointer and error.
+func getLatestCachedVersion(name, cacheDir, prefix string) (*string, error) {
+	entries, err := listCacheEntries(cacheDir, prefix)
+	if err != nil {
+		return nil, err
+	} else if len(entries) == 0 {
+		return nil, fmt.Errorf("offline mode is enabled and no cached %s versions found in %s", name, cacheDir)
+	}
+
+	logrus.Debugf("Latest cached %s version is: %s", name, entries[0].version)
+	return &entries[0].version, nil
+}
+
+// Configure resolved "latest" versions caching.
+//
+// Accept "latest" versions TTL and refresh flag.
+func configureLatestCache(ttl time.Duration, refresh bool) {
+	latestTTL = max(ttl, 0)
+	latestRefresh = refresh
+}
+
+// Read resolved "latest" versions, stored in cache.
+// Missing or malformed file is treated as empty.
+//
+// Accept cache root path.
+// Return map of tool names to resolved "latest" versions.
+func readLatestVersions(cacheDir string) map[string]latestVersion {
+	versions := make(map[string]latestVersion)
+
+	data, err := os.ReadFile(filepath.Join(cacheDir, LATEST_CACHE_FILE_NAME))
+	if err != nil {
+		return versions
+	}
+
+	err = json.Unmarshal(data, &versions)
+	if err != nil {
+		logrus.Debugf("Could not parse cached latest versions, ignoring: %v", err)
+		return make(map[string]latestVersion)
+	}
+
+	return versions
+}
+
+// Store resolved "latest" version in cache.
+// The file is locked while being updated, so that concurrent "protogo" runs don't overwrite each other's versions.
+//
+// Accept cache root path, tool name and resolved "latest" version.
+// Return error.
+func writeLatestVersion(cacheDir, name string, version latestVersion) error {
+	latestPath := filepath.Join(cacheDir, LATEST_CACHE_FILE_NAME)
+	unlock, err := lockCacheEntry(latestPath)
+	if err != nil {
+		return err
+	} else {
+		defer unlock()
+	}
+
+	versions := readLatestVersions(cacheDir)
+	versions[name] = version
+
+	data, err := json.MarshalIndent(versions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error serializing latest versions: %v", err)
+	}
+
+	err = os.WriteFile(latestPath, data, 0644)
+	if err != nil {
+		return fmt.Errorf("error writing latest versions to %s: %v", latestPath, err)
+	}
+
+	return nil
+}
+
+// Find "latest" version of a tool, stored in cache, if it was resolved less than TTL ago from the same source.
+// Stored versions are never used if caching is disabled or refresh is requested.
+//
+// Accept cache root path, tool name and source URL.
+// Return stored latest version string pointer and boolean flag, whether the version can be reused.
+func findStoredLatestVersion(cacheDir, name, source string) (*string, bool) {
+	if latestTTL <= 0 || latestRefresh {
+		return nil, false
+	}
+
+	cached, ok := readLatestVersions(cacheDir)[name]
+	if !ok || cached.Source != source || time.Since(cached.Resolved) >= latestTTL {
+		return nil, false
+	}
+
+	logrus.Debugf("Using cached latest %s version (resolved at %s): %s", name, cached.Resolved.Format(time.RFC3339), cached.Version)
+	return &cached.Version, true
+}
+
+// Store resolved "latest" version of a tool in cache (if caching is enabled).
+// Storing errors are not fatal, the version is just resolved again next time.
+//
+// Accept cache root path, tool name, source URL and resolved version.
+func storeLatestVersion(cacheDir, name, source, version string) {
+	if latestTTL > 0 {
+		err := writeLatestVersion(cacheDir, name, latestVersion{Version: version, Source: source, Resolved: time.Now()})
+		if err != nil {
+			logrus.Debugf("Could not store latest %s version in cache: %v", name, err)
+		}
+	}
+}
+
+// Resolve "latest" version of a tool, reusing the version stored in cache if it was resolved less than TTL ago from the same source.
+// Otherwise (or if refresh is requested) the version is resolved with the given function and stored in cache.
+//
+// Accept cache root path, tool name, source URL and function, resolving the latest version from network.
+// Return latest version string pointer and error.
+func getCachedLatestVersion(cacheDir, name, source string, resolve func() (*string, error)) (*string, error) {
+	if cached, ok := findStoredLatestVersion(cacheDir, name, source); ok {
+		return cached, nil
+	}
+
+	version, err := resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	storeLatestVersion(cacheDir, name, source, *version)
+	return version, nil
+}
+
+// Resolve "latest" version of a prebuilt plugin, reusing the version stored in cache if it was resolved less than TTL ago.
+//
+// Accept prebuilt plugin configuration and cache root path.
+// Return latest version string pointer and error.
+func getCachedLatestBinaryPluginVersion(plugin BinaryPluginConfig, cacheDir string) (*string, error) {
+	source := getDownloadBase(plugin.Mirror, fmt.Sprintf(LATEST_RELEASE, plugin.Repository))
+	return getCachedLatestVersion(cacheDir, plugin.Name, source, func() (*string, error) { return getLatestBinaryPluginVersion(plugin) })
+}
+
+// Make error for an archive that is not cached and can not be downloaded in offline mode.
+// List all the cached versions in the error message.
+//
+// Accept tool name, requested version, cache directory path and archive directory name prefix.
+// Return error.
+func makeOfflineMissingError(name, version, cacheDir, prefix string) error {
+	var cached []string
+	entries, _ := listCacheEntries(cacheDir, prefix)
+	for _, entry := range entries {
+		cached = append(cached, entry.version)
+	}
 
-	if value, ok := os.LookupEnv(key); ok {
-		versionTag = value
+	if len(cached) == 0 {
+		return fmt.Errorf("offline mode is enabled and %s %s is missing from cache %s (no versions cached)", name, version, cacheDir)
 	} else {
-		versionTag = "latest"
+		return fmt.Errorf("offline mode is enabled and %s %s is missing from cache %s (cached versions: %s)", name, version, cacheDir, strings.Join(cached, ", "))
 	}
+}
+
+// Resolve version alias ("latest-rc" or "previous-major") to an exact version.
+// The alias is resolved against available release versions (or against cached versions in offline mode).
+//
+// Accept tool name, version alias, cache directory path, archive directory name prefix and function, listing available release versions.
+// Return exact version string pointer and error.
+func resolveVersionAlias(name, alias, cacheDir, prefix string, listVersions func() ([]string, error)) (*string, error) {
+	var versions []string
+	if networkOffline {
+		entries, err := listCacheEntries(cacheDir, prefix)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			versions = append(versions, entry.version)
+		}
+	} else {
+		var err error
+		versions, err = listVersions()
+		if err != nil {
+			return nil, fmt.Errorf("error listing %s versions: %v", name, err)
+		}
+	}
+
+	version, ok := findAliasedVersion(alias, versions)
+	if !ok && networkOffline {
+		return nil, makeOfflineMissingError(name, alias, cacheDir, prefix)
+	} else if !ok {
+		return nil, fmt.Errorf("no %s release version found for '%s'", name, alias)
+	}
+
+	logrus.Debugf("Version alias %s of %s resolved to: %s", alias, name, version)
+	return &version, nil
+}
 
+// Resolve version constraint expression (e.g. "~25.1" or ">=23,<26") to an exact version.
+// The highest cached version, satisfying the constraint, is preferred.
+// Otherwise, the highest available release version, satisfying the constraint, is chosen (offline mode fails with an error listing cached versions).
+//
+// Accept tool name, constraint expression, cache directory path, archive directory name prefix and function, listing available release versions.
+// Return exact version string pointer and error.
+func resolveVersionConstraint(name, expression, cacheDir, prefix string, listVersions func() ([]string, error)) (*string, error) {
+	constraints, err := parseVersionConstraints(expression)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s version constraint '%s': %v", name, expression, err)
+	}
+
+	entries, err := listCacheEntries(cacheDir, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if matchesVersionConstraints(entry.version, constraints) {
+			logrus.Debugf("Cached %s version %s satisfies constraint '%s'", name, entry.version, expression)
+			return &entry.version, nil
+		}
+	}
+
+	if networkOffline {
+		return nil, makeOfflineMissingError(name, expression, cacheDir, prefix)
+	}
+
+	versions, err := listVersions()
+	if err != nil {
+		return nil, fmt.Errorf("error listing %s versions: %v", name, err)
+	}
+
+	version, ok := findHighestMatchingVersion(versions, constraints)
+	if !ok {
+		return nil, fmt.Errorf("no %s release version satisfies constraint '%s'", name, expression)
+	}
+
+	logrus.Debugf("Release %s version %s satisfies constraint '%s'", name, version, expression)
+	return &version, nil
+}
+
+// Get cached protobuf compiler by version.
+// Resolve requested protobuf version, find out the exact version name for "latest" (the latest cached version is used in offline mode, the resolved version is reused during TTL), for a version alias or for a version constraint.
+// Verify "protoc" is installed locally, if "local" is specified as version.
+// Search for the required version directory in cache otherwise, it should contain installation completion marker file.
+//
+// Accept protobuf compiler version (with or without "v" prefix, "latest", "local", a version alias or a constraint expression), mirror base URL (or empty string if none) and cache root path.
+// Return version tag string pointer, cache directory for the given version (or nil for "local"), boolean flag, whether protoc binary should be downloaded, and error.
+func getProtocCache(versionTag, mirror, cacheDir string) (*string, *string, bool, error) {
 	logrus.Debugf("Requested version tag is: %s", versionTag)
 	switch versionTag {
 	case "latest":
-		latestTag, err := getLatestProtocReleaseTag()
+		var latestTag *string
+		var err error
+		if networkOffline {
+			latestTag, err = getLatestCachedVersion(PROTOC_EXECUTABLE, cacheDir, PROTOC_CACHE_PREFIX)
+		} else {
+			latestTag, err = getCachedLatestVersion(cacheDir, PROTOC_EXECUTABLE, getDownloadBase(mirror, LATEST_PROTOC_RELEASE), func() (*string, error) { return getLatestProtocReleaseTag(mirror) })
+		}
 		if err != nil {
 			return nil, nil, false, fmt.Errorf("latest protoc version tag couldn't be resolved: %v", err)
 		}
 		versionTag = *latestTag
+	case VERSION_ALIAS_LATEST_RC, VERSION_ALIAS_PREVIOUS_MAJOR:
+		aliasedTag, err := resolveVersionAlias(PROTOC_EXECUTABLE, versionTag, cacheDir, PROTOC_CACHE_PREFIX, func() ([]string, error) { return listProtocVersions(mirror) })
+		if err != nil {
+			return nil, nil, false, fmt.Errorf("protoc version alias couldn't be resolved: %v", err)
+		}
+		versionTag = *aliasedTag
 	case "local":
 		_, err := exec.LookPath(PROTOC_EXECUTABLE)
 		if err != nil {
@@ -149,42 +515,48 @@ func getProtocCache(key, cacheDir string) (*string, *string, bool, error) {
 		}
 	}
 
-	versionTag = strings.TrimPrefix(versionTag, "v")
-	protocCache := filepath.Join(cacheDir, fmt.Sprintf("protoc-%s", versionTag))
-	protocExec := filepath.Join(protocCache, "bin", getExecutableName(PROTOC_EXECUTABLE))
-
-	_, err := os.Stat(protocExec)
-	if err != nil {
-		return &versionTag, &protocCache, true, nil
-	} else {
-		return &versionTag, &protocCache, false, nil
+	if isVersionConstraint(versionTag) {
+		resolvedTag, err := resolveVersionConstraint(PROTOC_EXECUTABLE, versionTag, cacheDir, PROTOC_CACHE_PREFIX, func() ([]string, error) { return listProtocVersions(mirror) })
+		if err != nil {
+			return nil, nil, false, fmt.Errorf("protoc version constraint couldn't be resolved: %v", err)
+		}
+		versionTag = *resolvedTag
 	}
+
+	versionTag = strings.TrimPrefix(versionTag, "v")
+	protocCache := filepath.Join(cacheDir, PROTOC_CACHE_PREFIX+versionTag)
+	shouldDownload := !isArchiveInstalled(protocCache, filepath.Join("bin", getExecutableName(PROTOC_EXECUTABLE)))
+	return &versionTag, &protocCache, shouldDownload, nil
 }
 
 // Get cached flatbuffers compiler by version.
-// Resolve requested flatbuffers version, find out the exact version name for "latest".
+// Resolve requested flatbuffers version, find out the exact version name for "latest" (the latest cached version is used in offline mode, the resolved version is reused during TTL), for a version alias or for a version constraint.
 // Verify "flatc" is installed locally, if "local" is specified as version.
-// Search for the required version directory in cache otherwise.
+// Search for the required version directory in cache otherwise, it should contain installation completion marker file.
 //
-// Accept flatbuffers compiler version environment variable (with or without "v" prefix, empty string if none) and cache root path.
+// Accept flatbuffers compiler version (with or without "v" prefix, "latest", "local", a version alias or a constraint expression), mirror base URL (or empty string if none) and cache root path.
 // Return version tag string pointer, cache directory for the given version (or nil for "local"), boolean flag, whether flatc binary should be downloaded, and error.
-func getFlatcCache(key, cacheDir string) (*string, *string, bool, error) {
-	var versionTag string
-
-	if value, ok := os.LookupEnv(key); ok {
-		versionTag = value
-	} else {
-		versionTag = "latest"
-	}
-
+func getFlatcCache(versionTag, mirror, cacheDir string) (*string, *string, bool, error) {
 	logrus.Debugf("Requested version tag is: %s", versionTag)
 	switch versionTag {
 	case "latest":
-		latestTag, err := getLatestFlatcReleaseTag()
+		var latestTag *string
+		var err error
+		if networkOffline {
+			latestTag, err = getLatestCachedVersion(FLATC_EXECUTABLE, cacheDir, FLATC_CACHE_PREFIX)
+		} else {
+			latestTag, err = getCachedLatestVersion(cacheDir, FLATC_EXECUTABLE, getDownloadBase(mirror, LATEST_FLATC_RELEASE), func() (*string, error) { return getLatestFlatcReleaseTag(mirror) })
+		}
 		if err != nil {
 			return nil, nil, false, fmt.Errorf("latest flatc version tag couldn't be resolved: %v", err)
 		}
 		versionTag = *latestTag
+	case VERSION_ALIAS_LATEST_RC, VERSION_ALIAS_PREVIOUS_MAJOR:
+		aliasedTag, err := resolveVersionAlias(FLATC_EXECUTABLE, versionTag, cacheDir, FLATC_CACHE_PREFIX, func() ([]string, error) { return listFlatcVersions(mirror) })
+		if err != nil {
+			return nil, nil, false, fmt.Errorf("flatc version alias couldn't be resolved: %v", err)
+		}
+		versionTag = *aliasedTag
 	case "local":
 		_, err := exec.LookPath(FLATC_EXECUTABLE)
 		if err != nil {
@@ -194,60 +566,248 @@ func getFlatcCache(key, cacheDir string) (*string, *string, bool, error) {
 		}
 	}
 
+	if isVersionConstraint(versionTag) {
+		resolvedTag, err := resolveVersionConstraint(FLATC_EXECUTABLE, versionTag, cacheDir, FLATC_CACHE_PREFIX, func() ([]string, error) { return listFlatcVersions(mirror) })
+		if err != nil {
+			return nil, nil, false, fmt.Errorf("flatc version constraint couldn't be resolved: %v", err)
+		}
+		versionTag = *resolvedTag
+	}
+
 	versionTag = strings.TrimPrefix(versionTag, "v")
-	flatcCache := filepath.Join(cacheDir, fmt.Sprintf("flatc-%s", versionTag))
-	flatcExec := filepath.Join(flatcCache, getExecutableName(FLATC_EXECUTABLE))
+	flatcCache := filepath.Join(cacheDir, FLATC_CACHE_PREFIX+versionTag)
+	shouldDownload := !isArchiveInstalled(flatcCache, getExecutableName(FLATC_EXECUTABLE))
+	return &versionTag, &flatcCache, shouldDownload, nil
+}
 
-	_, err := os.Stat(flatcExec)
+// Get cached prebuilt plugin by version.
+// Resolve requested plugin version, find out the exact version name for "latest" (the latest cached version is used in offline mode, the resolved version is reused during TTL).
+// Search for the required version directory in cache, it should contain installation completion marker file.
+//
+// Accept prebuilt plugin configuration, plugin version (with or without "v" prefix or "latest") and cache root path.
+// Return version string pointer, cache directory for the given version, plugin executable path (relative to the cache directory), boolean flag, whether plugin should be downloaded, and error.
+func getBinaryPluginCache(plugin BinaryPluginConfig, version, cacheDir string) (*string, *string, string, bool, error) {
+	pluginsCache := filepath.Join(cacheDir, PLUGINS_CACHE_DIR)
+	prefix := getPluginCachePrefix(plugin.Name)
+
+	logrus.Debugf("Requested %s version is: %s", plugin.Name, version)
+	if version == "latest" {
+		var latestVersion *string
+		var err error
+		if networkOffline {
+			latestVersion, err = getLatestCachedVersion(plugin.Name, pluginsCache, prefix)
+		} else {
+			latestVersion, err = getCachedLatestBinaryPluginVersion(plugin, cacheDir)
+		}
+		if err != nil {
+			return nil, nil, "", false, fmt.Errorf("latest %s version couldn't be resolved: %v", plugin.Name, err)
+		}
+		version = *latestVersion
+	}
+
+	version = strings.TrimPrefix(version, "v")
+	_, _, binary, err := getBinaryPluginFiles(plugin, version)
 	if err != nil {
-		return &versionTag, &flatcCache, true, nil
-	} else {
-		return &versionTag, &flatcCache, false, nil
+		return nil, nil, "", false, err
 	}
+
+	pluginCache := filepath.Join(pluginsCache, prefix+version)
+	shouldDownload := !isArchiveInstalled(pluginCache, binary)
+	return &version, &pluginCache, binary, shouldDownload, nil
 }
 
-// Get cached Google APIs library.
-// Search for the required directory in cache.
+// Get cached Google APIs library by revision.
+// Search for the required revision directory in cache, it should contain installation completion marker file.
+// In offline mode, if the default revision is requested but not cached, the most recently installed revision is used.
 //
-// Return cache directory for the given version (or nil for "local"), boolean flag, whether Google APIs library should be downloaded, and error.
-func getGoogleAPIsCache(cacheDir string) (string, bool, error) {
-	flatcCache := filepath.Join(cacheDir, "googleapis")
-	dir, err := os.Stat(flatcCache)
-	if err != nil || !dir.IsDir() {
-		return flatcCache, true, nil
-	} else {
-		return flatcCache, false, nil
+// Accept Google APIs library revision (branch name or commit hash) and cache root path.
+// Return resolved revision, Google APIs library cache root, boolean flag, whether Google APIs library should be downloaded, and error.
+func getGoogleAPIsCache(revision, cacheDir string) (string, string, bool, error) {
+	googleAPIsCache := filepath.Join(cacheDir, GOOGLEAPIS_CACHE_DIR)
+	googleAPIsDir := filepath.Join(googleAPIsCache, fmt.Sprintf(GOOGLEAPIS_DIR_NAME, revision))
+	if isArchiveInstalled(googleAPIsDir) {
+		return revision, googleAPIsCache, false, nil
+	} else if !networkOffline || revision != GOOGLEAPIS_DEFAULT_REVISION {
+		return revision, googleAPIsCache, true, nil
 	}
+
+	entries, err := listCacheEntries(googleAPIsCache, GOOGLEAPIS_DIR_PREFIX)
+	if err != nil {
+		return "", "", false, err
+	} else if len(entries) == 0 {
+		return revision, googleAPIsCache, true, nil
+	}
+
+	newest := slices.MaxFunc(entries, func(a, b cacheEntry) int {
+		return a.installed.Compare(b.installed)
+	})
+	logrus.Debugf("Most recently installed Google APIs revision is: %s", newest.version)
+	return newest.version, googleAPIsCache, false, nil
 }
 
-// Ensure GO binary (command) is installed locally.
-// Search for the package in the GO binary directory.
-// Install the package if it is not found (ensure correct GOOS and GOARCH during installation).
-// Search for the package in the GO binary directory again.
+// Install GO binary (command) of the given version to the given directory (ensure correct GOOS and GOARCH during installation).
+// In offline mode, only GO module cache is used for installation.
 //
-// Accept GO executable path, GO binary directory path, package prefix (without name) and package (command) name.
+// Accept GO executable path, binary installation directory (GOBIN), package prefix (without name), package (command) name and version (or "latest").
 // Return error.
-func ensureGoPackageInstalled(goExecutable, goBin, packagePrefix, packageName string) error {
-	packageExecutable := filepath.Join(goBin, packageName)
+func installGoPackage(goExecutable, goBin, packagePrefix, packageName, version string) error {
+	packageUrl := fmt.Sprintf("%s/%s@%s", packagePrefix, packageName, version)
+	logrus.Debugf("Installing package %s from %s to: %s", packageName, packageUrl, goBin)
+	cmd := exec.Command(goExecutable, "install", packageUrl)
+	cmd.Env = append(cmd.Environ(), fmt.Sprintf("GOBIN=%s", goBin), fmt.Sprintf("GOOS=%s", runtime.GOOS), fmt.Sprintf("GOARCH=%s", runtime.GOARCH))
+	if networkOffline {
+		logrus.Debug("Offline mode is enabled, only GO module cache will be used")
+		cmd.Env = append(cmd.Env, "GOPROXY=off")
+	}
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("error installing package %s: %v\n%s", packageName, err, string(output))
+	}
+
+	return nil
+}
+
+// GO module file contents, as printed by "go mod edit -json" command (only the used fields).
+type goModFile struct {
+	Require []struct {
+		Path    string
+		Version string
+	}
+	Tool []struct {
+		Path string
+	}
+}
 
-	_, err := exec.LookPath(packageExecutable)
-	if err == nil {
-		return nil
+// Read the current GO module file, by running "go mod edit -json" command on the module "go.mod" file.
+//
+// Accept GO executable path.
+// Return GO module file contents pointer (or nil if there is no current module) and error.
+func readGoModFile(goExecutable string) (*goModFile, error) {
+	goMod, ok := lookupGoEnv(goExecutable, "GOMOD")
+	if !ok || goMod == os.DevNull {
+		logrus.Debug("No current GO module found!")
+		return nil, nil
 	}
 
-	packageUrl := fmt.Sprintf("%s/%s@latest", packagePrefix, packageName)
-	logrus.Debugf("Package %s is not installed, installing latest version from: %s", packageName, packageUrl)
-	cmd := exec.Command(goExecutable, "install", packageUrl)
+	cmd := exec.Command(goExecutable, "mod", "edit", "-json", goMod)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("error reading GO module file %s: %v", goMod, err)
+	}
+
+	var module goModFile
+	err = json.Unmarshal(output, &module)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing GO module file %s: %v", goMod, err)
+	}
+
+	return &module, nil
+}
+
+// Get module requirements of the current GO module.
+// Both direct and indirect requirements are included.
+//
+// Accept GO executable path.
+// Return module path to version map and error (empty map if there is no current module).
+func getGoModRequirements(goExecutable string) (map[string]string, error) {
+	requirements := make(map[string]string)
+
+	module, err := readGoModFile(goExecutable)
+	if err != nil {
+		return nil, err
+	} else if module == nil {
+		return requirements, nil
+	}
+
+	for _, requirement := range module.Require {
+		requirements[requirement.Path] = requirement.Version
+	}
+	return requirements, nil
+}
+
+// Get command name of GO package, just like "go install" names binaries: the last package path element,
+// unless it is a major version suffix (e.g. "example.com/protoc-gen-foo/v2" is named "protoc-gen-foo").
+//
+// Accept GO package path.
+// Return command name.
+func getGoCommandName(packagePath string) string {
+	dir, name := path.Split(packagePath)
+	if dir == "" || len(name) < 2 || name[0] != 'v' || name == "v0" || name == "v1" || name[1] == '0' {
+		return name
+	}
+
+	for _, char := range name[1:] {
+		if char < '0' || char > '9' {
+			return name
+		}
+	}
+	return path.Base(dir)
+}
+
+// Get tools of the current GO module, declared with "tool" directives (supported since GO 1.24).
+// Tools are named by their command names (see "getGoCommandName").
+//
+// Accept GO executable path.
+// Return tool command name to package path map and error (empty map if there is no current module).
+func getGoModTools(goExecutable string) (map[string]string, error) {
+	tools := make(map[string]string)
+
+	module, err := readGoModFile(goExecutable)
+	if err != nil {
+		return nil, err
+	} else if module == nil {
+		return tools, nil
+	}
+
+	for _, tool := range module.Tool {
+		tools[getGoCommandName(tool.Path)] = tool.Path
+	}
+	return tools, nil
+}
+
+// Build GO module tool and get its executable path, by running "go tool -n ..." command.
+// The tool is built for the current platform with the versions, required in "go.mod" (and verified with "go.sum"), and is cached in GO build cache.
+//
+// Accept GO executable path and tool package path.
+// Return tool executable path and error.
+func getGoToolExecutable(goExecutable, toolPackage string) (string, error) {
+	cmd := exec.Command(goExecutable, "tool", "-n", toolPackage)
 	cmd.Env = append(cmd.Environ(), fmt.Sprintf("GOOS=%s", runtime.GOOS), fmt.Sprintf("GOARCH=%s", runtime.GOARCH))
-	output, err := cmd.CombinedOutput()
+	if networkOffline {
+		logrus.Debug("Offline mode is enabled, only GO module cache will be used")
+		cmd.Env = append(cmd.Env, "GOPROXY=off")
+	}
+	cmd.Stderr = os.Stderr
+	output, err := cmd.Output()
 	if err != nil {
-		return fmt.Errorf("error installing package %s: %v\n%s", packageName, err, string(output))
+		return "", fmt.Errorf("error building GO tool %s: %v", toolPackage, err)
 	}
 
-	_, err = exec.LookPath(packageExecutable)
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	executable := strings.TrimSpace(lines[len(lines)-1])
+	if executable == "" {
+		return "", fmt.Errorf("executable path of GO tool %s not found", toolPackage)
+	}
+	return executable, nil
+}
+
+// Get module path and version of an installed GO binary, by running "go version -m ..." command.
+//
+// Accept GO executable path and GO binary path.
+// Return module path, module version and error.
+func getGoPackageVersion(goExecutable, packageExecutable string) (string, string, error) {
+	cmd := exec.Command(goExecutable, "version", "-m", packageExecutable)
+	output, err := cmd.Output()
 	if err != nil {
-		return fmt.Errorf("after installation, still could not find package %s: %v", packageName, err)
+		return "", "", fmt.Errorf("error reading build info of %s: %v", packageExecutable, err)
 	}
 
-	return nil
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 3 && fields[0] == "mod" {
+			return fields[1], fields[2], nil
+		}
+	}
+
+	return "", "", fmt.Errorf("module info not found in build info of %s", packageExecutable)
 }
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFindStoredLatestVersion(t *testing.T) {
+	cacheDir := t.TempDir()
+	stored := map[string]latestVersion{
+		"fresh":    {Version: "v1.2.0", Source: "example.com/fresh", Resolved: time.Now().Add(-time.Hour)},
+		"outdated": {Version: "v1.1.0", Source: "example.com/outdated", Resolved: time.Now().Add(-48 * time.Hour)},
+	}
+	for name, version := range stored {
+		if err := writeLatestVersion(cacheDir, name, version); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		source   string
+		ttl      time.Duration
+		refresh  bool
+		expected string
+		found    bool
+	}{
+		{name: "fresh", source: "example.com/fresh", ttl: DEFAULT_LATEST_TTL, expected: "v1.2.0", found: true},
+		{name: "fresh", source: "mirror.example.com/fresh", ttl: DEFAULT_LATEST_TTL},
+		{name: "fresh", source: "example.com/fresh", ttl: DEFAULT_LATEST_TTL, refresh: true},
+		{name: "fresh", source: "example.com/fresh", ttl: 0},
+		{name: "fresh", source: "example.com/fresh", ttl: time.Minute},
+		{name: "outdated", source: "example.com/outdated", ttl: DEFAULT_LATEST_TTL},
+		{name: "missing", source: "example.com/missing", ttl: DEFAULT_LATEST_TTL},
+	}
+
+	defer configureLatestCache(DEFAULT_LATEST_TTL, false)
+	for _, test := range tests {
+		configureLatestCache(test.ttl, test.refresh)
+		version, found := findStoredLatestVersion(cacheDir, test.name, test.source)
+		if found != test.found || (found && *version != test.expected) {
+			t.Errorf("%s from %s (TTL %v, refresh %v): got found %v, expected %v (%s)", test.name, test.source, test.ttl, test.refresh, found, test.found, test.expected)
+		}
+	}
+
+	configureLatestCache(DEFAULT_LATEST_TTL, false)
+	storeLatestVersion(cacheDir, "outdated", "example.com/outdated", "v1.3.0")
+	if version, found := findStoredLatestVersion(cacheDir, "outdated", "example.com/outdated"); !found || *version != "v1.3.0" {
+		t.Errorf("stored latest version was not updated")
+	}
+}
+
+func TestGetGoCommandName(t *testing.T) {
+	tests := []struct {
+		packagePath string
+		expected    string
+	}{
+		{packagePath: "google.golang.org/protobuf/cmd/protoc-gen-go", expected: "protoc-gen-go"},
+		{packagePath: "example.com/protoc-gen-foo/v2", expected: "protoc-gen-foo"},
+		{packagePath: "example.com/protoc-gen-foo/v10", expected: "protoc-gen-foo"},
+		{packagePath: "example.com/protoc-gen-foo/v1", expected: "v1"},
+		{packagePath: "example.com/protoc-gen-foo/v02", expected: "v02"},
+		{packagePath: "example.com/protoc-gen-foo/v2beta", expected: "v2beta"},
+		{packagePath: "v2", expected: "v2"},
+	}
+
+	for _, test := range tests {
+		if actual := getGoCommandName(test.packagePath); actual != test.expected {
+			t.Errorf("getGoCommandName(%s) = %s, expected %s", test.packagePath, actual, test.expected)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+)
+
+const CACHE_LOCK_SUFFIX = ".lock"
+
+// Acquire exclusive advisory lock on a cache entry, waiting until it is released by other processes.
+// Lock file "[ENTRY].lock" is created next to the entry directory and is never removed.
+//
+// Accept cache entry directory path.
+// Return lock release function and error.
+func lockCacheEntry(entryDir string) (func(), error) {
+	lockPath := entryDir + CACHE_LOCK_SUFFIX
+
+	err := os.MkdirAll(filepath.Dir(lockPath), os.ModePerm)
+	if err != nil {
+		return nil, fmt.Errorf("error making directory for lock %s: %v", lockPath, err)
+	}
+
+	file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening lock file %s: %v", lockPath, err)
+	}
+
+	logrus.Debugf("Acquiring cache entry lock: %s", lockPath)
+	err = lockFile(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error acquiring lock %s: %v", lockPath, err)
+	} else {
+		logrus.Debugf("Cache entry lock acquired: %s", lockPath)
+	}
+
+	return func() {
+		err := unlockFile(file)
+		if err != nil {
+			logrus.Warnf("Error releasing lock %s: %v", lockPath, err)
+		}
+		file.Close()
+	}, nil
+}
@@ -0,0 +1,26 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package main
+
+import (
+	"os"
+
+	"github.com/sirupsen/logrus"
+)
+
+// File locking is not supported on this platform, so concurrent "protogo" runs are not synchronized.
+//
+// Accept file pointer.
+// Return error.
+func lockFile(file *os.File) error {
+	logrus.Debugf("File locking is not supported on this platform, lock %s is ignored", file.Name())
+	return nil
+}
+
+// File locking is not supported on this platform, nothing to unlock.
+//
+// Accept file pointer.
+// Return error.
+func unlockFile(_ *os.File) error {
+	return nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	GITHUB_API_HOST          = "api.github.com"
+	GITHUB_HOST              = "github.com"
+	NETRC_FILE_NAME          = ".netrc"
+	NETRC_WINDOWS_FILE_NAME  = "_netrc"
+	RATE_LIMIT_REMAINING     = "X-RateLimit-Remaining"
+	RATE_LIMIT_RESET         = "X-RateLimit-Reset"
+	RATE_LIMIT_LIMIT         = "X-RateLimit-Limit"
+	RETRY_AFTER              = "Retry-After"
+	RATE_LIMIT_RESET_PADDING = time.Second
+)
+
+// Environment variables, that can contain GitHub token, in the order of precedence.
+var githubTokenVariables = []string{"PROTOGO_GITHUB_BEARER_TOKEN", "GITHUB_TOKEN"}
+
+// Find netrc file: either specified by "NETRC" environment variable or located in user home directory.
+//
+// Return netrc file path (or empty string if it can not be resolved).
+func findNetrcFile() string {
+	if value, ok := os.LookupEnv("NETRC"); ok {
+		return value
+	}
+
+	userHome, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	if runtime.GOOS == "windows" {
+		return filepath.Join(userHome, NETRC_WINDOWS_FILE_NAME)
+	} else {
+		return filepath.Join(userHome, NETRC_FILE_NAME)
+	}
+}
+
+// Find password for the given machine in netrc file.
+// Only "machine", "login", "password" and "default" tokens are recognized, macros are not supported.
+//
+// Accept netrc file path and machine (host) name.
+// Return password and boolean flag, whether the password was found.
+func lookupNetrcPassword(path, machine string) (string, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+
+	current := ""
+	tokens := strings.Fields(string(data))
+	for i := 0; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "machine":
+			if i+1 < len(tokens) {
+				i++
+				current = tokens[i]
+			}
+		case "default":
+			current = ""
+		case "login", "account":
+			i++
+		case "password":
+			if i+1 < len(tokens) {
+				i++
+				if current == machine {
+					return tokens[i], true
+				}
+			}
+		}
+	}
+
+	return "", false
+}
+
+// Find GitHub API token.
+// Look it up in "PROTOGO_GITHUB_BEARER_TOKEN" environment variable, then in "GITHUB_TOKEN", then in netrc file ("api.github.com" or "github.com" machine password).
+//
+// Return token, its source description (for logging) and boolean flag, whether the token was found.
+func getGitHubToken() (string, string, bool) {
+	for _, variable := range githubTokenVariables {
+		if value, ok := os.LookupEnv(variable); ok && value != "" {
+			return value, variable, true
+		}
+	}
+
+	if netrc := findNetrcFile(); netrc != "" {
+		for _, machine := range []string{GITHUB_API_HOST, GITHUB_HOST} {
+			if password, ok := lookupNetrcPassword(netrc, machine); ok {
+				return password, fmt.Sprintf("%s (machine %s)", netrc, machine), true
+			}
+		}
+	}
+
+	return "", "", false
+}
+
+// Check whether GitHub API response means that the rate limit is exceeded.
+// Both primary ("X-RateLimit-Remaining: 0") and secondary ("Retry-After") rate limits are recognized.
+//
+// Accept HTTP response pointer.
+// Return duration to wait until the rate limit is reset and boolean flag, whether the rate limit is exceeded.
+func getRateLimitWait(resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(resp.Header.Get(RETRY_AFTER)); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if resp.Header.Get(RATE_LIMIT_REMAINING) != "0" {
+		return 0, false
+	}
+
+	reset, err := strconv.ParseInt(resp.Header.Get(RATE_LIMIT_RESET), 10, 64)
+	if err != nil {
+		return 0, true
+	}
+
+	return max(time.Until(time.Unix(reset, 0))+RATE_LIMIT_RESET_PADDING, 0), true
+}
+
+// Make error for exceeded GitHub API rate limit, containing the limit, reset time and advice on authentication.
+//
+// Accept request URL, HTTP response pointer and duration to wait until the rate limit is reset.
+// Return error.
+func makeRateLimitError(url string, resp *http.Response, wait time.Duration) error {
+	limit := resp.Header.Get(RATE_LIMIT_LIMIT)
+	if limit == "" {
+		limit = "unknown"
+	}
+
+	reset := time.Now().Add(wait).Format(time.RFC3339)
+	if _, _, ok := getGitHubToken(); ok {
+		return fmt.Errorf("GitHub API rate limit (%s requests) exceeded for %s, resets at %s (in %v)", limit, url, reset, wait.Round(time.Second))
+	} else {
+		return fmt.Errorf("GitHub API rate limit (%s requests) exceeded for %s, resets at %s (in %v), set GitHub token in PROTOGO_GITHUB_BEARER_TOKEN or GITHUB_TOKEN environment variable or in netrc file to increase the limit", limit, url, reset, wait.Round(time.Second))
+	}
+}
+
+// Log remaining GitHub API rate limit, if the response contains rate limit headers.
+//
+// Accept HTTP response pointer.
+func logRateLimit(resp *http.Response) {
+	if remaining := resp.Header.Get(RATE_LIMIT_REMAINING); remaining != "" {
+		logrus.Debugf("GitHub API rate limit remaining: %s of %s", remaining, resp.Header.Get(RATE_LIMIT_LIMIT))
+	}
+}
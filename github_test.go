@@ -0,0 +1,83 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestLookupNetrcPassword(t *testing.T) {
+	netrc := filepath.Join(t.TempDir(), ".netrc")
+	data := "machine example.com login user password example-secret\n" +
+		"machine api.github.com\n  login token\n  password api-secret\n" +
+		"machine github.com login password password login-named\n" +
+		"machine nopassword.com login user\n" +
+		"default login anonymous password default-secret\n"
+	err := os.WriteFile(netrc, []byte(data), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		machine  string
+		expected string
+		found    bool
+	}{
+		{name: "single line entry", path: netrc, machine: "example.com", expected: "example-secret", found: true},
+		{name: "multi line entry", path: netrc, machine: "api.github.com", expected: "api-secret", found: true},
+		{name: "login equal to keyword", path: netrc, machine: "github.com", expected: "login-named", found: true},
+		{name: "entry without password", path: netrc, machine: "nopassword.com"},
+		{name: "default entry is not used", path: netrc, machine: "unknown.com"},
+		{name: "missing file", path: filepath.Join(t.TempDir(), "missing"), machine: "example.com"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			password, found := lookupNetrcPassword(test.path, test.machine)
+			if found != test.found || password != test.expected {
+				t.Errorf("got ('%s', %v), expected ('%s', %v)", password, found, test.expected, test.found)
+			}
+		})
+	}
+}
+
+func TestGetRateLimitWait(t *testing.T) {
+	reset := strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10)
+
+	tests := []struct {
+		name    string
+		status  int
+		headers map[string]string
+		minimum time.Duration
+		maximum time.Duration
+		limited bool
+	}{
+		{name: "success", status: http.StatusOK, headers: map[string]string{RATE_LIMIT_REMAINING: "0", RATE_LIMIT_RESET: reset}},
+		{name: "forbidden without rate limit", status: http.StatusForbidden, headers: map[string]string{RATE_LIMIT_REMAINING: "10"}},
+		{name: "secondary rate limit", status: http.StatusTooManyRequests, headers: map[string]string{RETRY_AFTER: "30"}, minimum: 30 * time.Second, maximum: 30 * time.Second, limited: true},
+		{name: "primary rate limit", status: http.StatusForbidden, headers: map[string]string{RATE_LIMIT_REMAINING: "0", RATE_LIMIT_RESET: reset}, minimum: 50 * time.Second, maximum: time.Minute + RATE_LIMIT_RESET_PADDING, limited: true},
+		{name: "primary rate limit without reset", status: http.StatusForbidden, headers: map[string]string{RATE_LIMIT_REMAINING: "0"}, limited: true},
+		{name: "primary rate limit already reset", status: http.StatusForbidden, headers: map[string]string{RATE_LIMIT_REMAINING: "0", RATE_LIMIT_RESET: "0"}, limited: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := &http.Response{StatusCode: test.status, Header: http.Header{}}
+			for key, value := range test.headers {
+				res.Header.Set(key, value)
+			}
+
+			wait, limited := getRateLimitWait(res)
+			if limited != test.limited {
+				t.Errorf("got limited %v, expected %v", limited, test.limited)
+			} else if wait < test.minimum || wait > test.maximum {
+				t.Errorf("got wait %v, expected between %v and %v", wait, test.minimum, test.maximum)
+			}
+		})
+	}
+}
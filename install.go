@@ -0,0 +1,387 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Ensure protobuf compiler of the given version is available.
+// Resolve the version, download the compiler if it is not found in cache (fail in offline mode).
+// Cache entry is locked while downloading, so that concurrent "protogo" runs download it only once.
+//
+// Accept protobuf compiler version (with or without "v" prefix, "latest", "local" or a constraint expression) cache root path and known archive checksums (asset name to SHA-256 digest map).
+// Return compiler executable path, installed archive info pointer (nil for "local" version or if corrupted) and error.
+func ensureProtoc(version, mirror, cacheDir string, checksums map[string]string) (string, *ArchiveInfo, error) {
+	protocTag, protocCache, shouldDownload, err := getProtocCache(version, mirror, cacheDir)
+	if err != nil {
+		return "", nil, fmt.Errorf("could not find or load protoc executable: %v", err)
+	} else if protocCache != nil {
+		logrus.Debugf("Protoc version requested: %s, cache location: %s, will be downloaded: %t", *protocTag, *protocCache, shouldDownload)
+	} else {
+		logrus.Debugf("Protoc version requested: %s, system default, will not be downloaded", *protocTag)
+	}
+
+	if shouldDownload && !networkOffline {
+		unlock, err := lockCacheEntry(*protocCache)
+		if err != nil {
+			return "", nil, fmt.Errorf("could not lock protoc cache: %v", err)
+		} else {
+			defer unlock()
+		}
+		shouldDownload = !isArchiveInstalled(*protocCache, filepath.Join("bin", getExecutableName(PROTOC_EXECUTABLE)))
+	}
+
+	if shouldDownload && networkOffline {
+		return "", nil, makeOfflineMissingError(PROTOC_EXECUTABLE, *protocTag, cacheDir, PROTOC_CACHE_PREFIX)
+	} else if shouldDownload {
+		logrus.Debug("Downloading protoc executable...")
+		protocExec, info, err := downloadProtocVersion(*protocTag, mirror, *protocCache, checksums)
+		if err != nil {
+			return "", nil, fmt.Errorf("could not download or extract protoc: %v", err)
+		}
+		logrus.Debugf("Protoc executable downloaded to: %s", *protocExec)
+		markCacheEntryUsed(*protocCache)
+		return *protocExec, info, nil
+	} else if protocCache != nil {
+		protocExec := filepath.Join(*protocCache, "bin", getExecutableName(PROTOC_EXECUTABLE))
+		logrus.Debugf("Protoc executable found at: %s", protocExec)
+		markCacheEntryUsed(*protocCache)
+		return protocExec, readArchiveInfo(*protocCache), nil
+	} else {
+		logrus.Debugf("Protoc executable found at: %s", PROTOC_EXECUTABLE)
+		return PROTOC_EXECUTABLE, nil, nil
+	}
+}
+
+// Ensure flatbuffers compiler of the given version is available.
+// Resolve the version, download the compiler if it is not found in cache (fail in offline mode).
+// Cache entry is locked while downloading, so that concurrent "protogo" runs download it only once.
+//
+// Accept flatbuffers compiler version (with or without "v" prefix, "latest", "local" or a constraint expression), linux distribution of flatc, mirror base URL (or empty string if none), cache root path and known archive checksums (asset name to SHA-256 digest map).
+// Return compiler executable path, installed archive info pointer (nil for "local" version or if corrupted) and error.
+func ensureFlatc(version, distro, mirror, cacheDir string, checksums map[string]string) (string, *ArchiveInfo, error) {
+	flatcTag, flatcCache, shouldDownload, err := getFlatcCache(version, mirror, cacheDir)
+	if err != nil {
+		return "", nil, fmt.Errorf("could not find or load flatc executable: %v", err)
+	} else if flatcCache != nil {
+		logrus.Debugf("Flatc version requested: %s, cache location: %s, will be downloaded: %t", *flatcTag, *flatcCache, shouldDownload)
+	} else {
+		logrus.Debugf("Flatc version requested: %s, system default, will not be downloaded", *flatcTag)
+	}
+
+	if shouldDownload && !networkOffline {
+		unlock, err := lockCacheEntry(*flatcCache)
+		if err != nil {
+			return "", nil, fmt.Errorf("could not lock flatc cache: %v", err)
+		} else {
+			defer unlock()
+		}
+		shouldDownload = !isArchiveInstalled(*flatcCache, getExecutableName(FLATC_EXECUTABLE))
+	}
+
+	if shouldDownload && networkOffline {
+		return "", nil, makeOfflineMissingError(FLATC_EXECUTABLE, *flatcTag, cacheDir, FLATC_CACHE_PREFIX)
+	} else if shouldDownload {
+		logrus.Debug("Downloading flatc executable...")
+		flatcExec, info, err := downloadFlatcVersion(*flatcTag, distro, mirror, *flatcCache, checksums)
+		if err != nil {
+			return "", nil, fmt.Errorf("could not download or extract flatc: %v", err)
+		}
+		logrus.Debugf("Flatc executable downloaded to: %s", *flatcExec)
+		markCacheEntryUsed(*flatcCache)
+		return *flatcExec, info, nil
+	} else if flatcCache != nil {
+		flatcExec := filepath.Join(*flatcCache, getExecutableName(FLATC_EXECUTABLE))
+		logrus.Debugf("Flatc executable found at: %s", flatcExec)
+		markCacheEntryUsed(*flatcCache)
+		return flatcExec, readArchiveInfo(*flatcCache), nil
+	} else {
+		logrus.Debugf("Flatc executable found at: %s", FLATC_EXECUTABLE)
+		return FLATC_EXECUTABLE, nil, nil
+	}
+}
+
+// Ensure Google APIs library of the given revision is available.
+// Download the library if it is not found in cache (fail in offline mode).
+// Cache entry is locked while downloading, so that concurrent "protogo" runs download it only once.
+//
+// Accept Google APIs library revision (branch name or commit hash), mirror base URL (or empty string if none), cache root path and known archive checksums (asset name to SHA-256 digest map).
+// Return Google APIs library path, installed archive info pointer (nil if corrupted) and error.
+func ensureGoogleAPIs(revision, mirror, cacheDir string, checksums map[string]string) (string, *ArchiveInfo, error) {
+	revision, googleAPIsCache, shouldDownload, err := getGoogleAPIsCache(revision, cacheDir)
+	if err != nil {
+		return "", nil, fmt.Errorf("could not find or load Google APIs library: %v", err)
+	} else {
+		logrus.Debugf("Google APIs revision requested: %s, cache location: %s, will be downloaded: %t", revision, googleAPIsCache, shouldDownload)
+	}
+
+	googleAPIsDir := filepath.Join(googleAPIsCache, fmt.Sprintf(GOOGLEAPIS_DIR_NAME, revision))
+	if shouldDownload && !networkOffline {
+		unlock, err := lockCacheEntry(googleAPIsDir)
+		if err != nil {
+			return "", nil, fmt.Errorf("could not lock Google APIs library cache: %v", err)
+		} else {
+			defer unlock()
+		}
+		shouldDownload = !isArchiveInstalled(googleAPIsDir)
+	}
+
+	if shouldDownload && networkOffline {
+		return "", nil, makeOfflineMissingError("Google APIs library", revision, googleAPIsCache, GOOGLEAPIS_DIR_PREFIX)
+	} else if shouldDownload {
+		logrus.Debug("Downloading Google APIs library...")
+		googleAPIs, info, err := downloadGoogleAPIsVersion(revision, mirror, googleAPIsCache, checksums)
+		if err != nil {
+			return "", nil, fmt.Errorf("could not download or extract Google APIs library: %v", err)
+		}
+		logrus.Debugf("Google APIs library downloaded to: %s", *googleAPIs)
+		markCacheEntryUsed(*googleAPIs)
+		return *googleAPIs, info, nil
+	} else {
+		logrus.Debugf("Google APIs library found at: %s", googleAPIsDir)
+		markCacheEntryUsed(googleAPIsDir)
+		return googleAPIsDir, readArchiveInfo(googleAPIsDir), nil
+	}
+}
+
+// Ensure prebuilt plugin of the given version is available.
+// Resolve the version, download the plugin if it is not found in cache (fail in offline mode).
+// Cache entry is locked while downloading, so that concurrent "protogo" runs download it only once.
+//
+// Accept prebuilt plugin configuration, plugin version (with or without "v" prefix or "latest"), cache root path and known archive checksums (asset name to SHA-256 digest map).
+// Return plugin executable path, installed archive info pointer (nil if corrupted) and error.
+func ensureBinaryPlugin(plugin BinaryPluginConfig, version, cacheDir string, checksums map[string]string) (string, *ArchiveInfo, error) {
+	pluginVersion, pluginCache, pluginBinary, shouldDownload, err := getBinaryPluginCache(plugin, version, cacheDir)
+	if err != nil {
+		return "", nil, fmt.Errorf("could not find or load %s: %v", plugin.Name, err)
+	} else {
+		logrus.Debugf("%s version requested: %s, cache location: %s, will be downloaded: %t", plugin.Name, *pluginVersion, *pluginCache, shouldDownload)
+	}
+
+	if shouldDownload && !networkOffline {
+		unlock, err := lockCacheEntry(*pluginCache)
+		if err != nil {
+			return "", nil, fmt.Errorf("could not lock %s cache: %v", plugin.Name, err)
+		} else {
+			defer unlock()
+		}
+		shouldDownload = !isArchiveInstalled(*pluginCache, pluginBinary)
+	}
+
+	if shouldDownload && networkOffline {
+		return "", nil, makeOfflineMissingError(plugin.Name, *pluginVersion, filepath.Join(cacheDir, PLUGINS_CACHE_DIR), getPluginCachePrefix(plugin.Name))
+	} else if shouldDownload {
+		logrus.Debugf("Downloading %s executable...", plugin.Name)
+		downloadedExec, info, err := downloadBinaryPluginVersion(plugin, *pluginVersion, *pluginCache, checksums)
+		if err != nil {
+			return "", nil, fmt.Errorf("could not download or extract %s: %v", plugin.Name, err)
+		}
+		logrus.Debugf("%s executable downloaded to: %s", plugin.Name, *downloadedExec)
+		markCacheEntryUsed(*pluginCache)
+		return *downloadedExec, info, nil
+	} else {
+		pluginExec := filepath.Join(*pluginCache, pluginBinary)
+		logrus.Debugf("%s executable found at: %s", plugin.Name, pluginExec)
+		markCacheEntryUsed(*pluginCache)
+		return pluginExec, readArchiveInfo(*pluginCache), nil
+	}
+}
+
+// Install GO plugin of the given version to a staging directory inside the plugins cache directory.
+// Staging directory is removed on interruption, otherwise it should be removed by the caller.
+//
+// Accept GO executable path, plugins cache directory path, plugin and version (or "latest").
+// Return staging directory path, exact installed module version and error.
+func stageGoPackage(goExecutable, pluginsCache string, plugin goPackage, version string) (string, string, error) {
+	err := os.MkdirAll(pluginsCache, os.ModePerm)
+	if err != nil {
+		return "", "", fmt.Errorf("error making plugins cache directory %s: %v", pluginsCache, err)
+	}
+
+	stagingDir, err := os.MkdirTemp(pluginsCache, "."+plugin.name+CACHE_STAGING_INFIX+"*")
+	if err != nil {
+		return "", "", fmt.Errorf("error making staging directory in %s: %v", pluginsCache, err)
+	} else {
+		registerCleanup(stagingDir)
+	}
+
+	absoluteStagingDir, err := filepath.Abs(stagingDir)
+	if err != nil {
+		return stagingDir, "", fmt.Errorf("error resolving staging directory %s: %v", stagingDir, err)
+	}
+
+	err = installGoPackage(goExecutable, absoluteStagingDir, plugin.prefix, plugin.name, version)
+	if err != nil {
+		return stagingDir, "", err
+	}
+
+	_, installed, err := getGoPackageVersion(goExecutable, filepath.Join(stagingDir, getExecutableName(plugin.name)))
+	if err != nil {
+		return stagingDir, "", fmt.Errorf("could not get package %s version: %v", plugin.name, err)
+	}
+
+	return stagingDir, installed, nil
+}
+
+// Get version of GO plugin installed to the cache entry directory, from the plugin build info (see "getGoPackageVersion").
+// Prebuilt plugins may be built outside of module mode (with "(devel)" module version), the downloaded release version is used for them.
+//
+// Accept GO executable path, cache entry directory and plugin executable name.
+// Return plugin version and error.
+func getInstalledGoPackageVersion(goExecutable, entryDir, executable string) (string, error) {
+	_, installed, err := getGoPackageVersion(goExecutable, filepath.Join(entryDir, executable))
+	if err != nil {
+		return "", err
+	}
+
+	if info := readArchiveInfo(entryDir); installed == PLUGIN_VERSION_DEVEL && info != nil {
+		logrus.Debugf("Prebuilt %s module version is unknown, release version is used: %s", executable, info.Version)
+		return info.Version, nil
+	}
+
+	return installed, nil
+}
+
+// Download prebuilt GO plugin release asset of the given version and install it to the cache entry directory.
+// Entry directory is expected to be locked by the caller.
+//
+// Accept GO executable path, plugin (with prebuilt release assets), version, cache entry directory and known archive checksums (asset name to SHA-256 digest map).
+// Return error (if the plugin has no asset for the current platform, download failed or the downloaded plugin version doesn't match).
+func installPrebuiltGoPackage(goExecutable string, plugin goPackage, version, entryDir string, checksums map[string]string) error {
+	_, _, err := downloadBinaryPluginVersion(*plugin.release, strings.TrimPrefix(version, "v"), entryDir, checksums)
+	if err != nil {
+		return err
+	}
+
+	installed, err := getInstalledGoPackageVersion(goExecutable, entryDir, getExecutableName(plugin.name))
+	if err != nil {
+		return fmt.Errorf("prebuilt %s version couldn't be checked: %v", plugin.name, err)
+	} else if strings.TrimPrefix(installed, "v") != strings.TrimPrefix(version, "v") {
+		return fmt.Errorf("prebuilt %s version %s doesn't match required version %s", plugin.name, installed, version)
+	}
+
+	return nil
+}
+
+// Ensure GO plugin of the given version is installed to the plugins cache directory.
+// Every plugin version is installed to its own "plugins/[NAME]@[VERSION]" directory, so that different versions never clash.
+// Version queries ("latest" or a version prefix, e.g. "v1.34") are resolved first: the version, installed with "go install" for the same query
+// less than "latest" TTL ago, is used (unless refresh is requested), in offline mode the highest matching cached version is used.
+// If the plugin is published as prebuilt release assets, the asset for the current platform is downloaded (and verified),
+// the plugin is installed with "go install" if there is no such asset or it can not be downloaded.
+// Installed plugin version is read from its build info ("go version -m"), the plugin is reinstalled if it doesn't match.
+// Cache entry is locked while installing, so that concurrent "protogo" runs install it only once.
+//
+// Accept GO executable path, cache root path, plugin, version (or version query) and known archive checksums (asset name to SHA-256 digest map).
+// Return plugin directory path (containing plugin executable only) and error.
+func ensureGoPackageInstalled(goExecutable, cacheDir string, plugin goPackage, version string, checksums map[string]string) (string, error) {
+	pluginsCache := filepath.Join(cacheDir, PLUGINS_CACHE_DIR)
+	prefix := getPluginCachePrefix(plugin.name)
+	executable := getExecutableName(plugin.name)
+	packagePath := fmt.Sprintf("%s/%s", plugin.prefix, plugin.name)
+	query := ""
+	if !isFullPluginVersion(version) {
+		query = version
+	}
+
+	if query != "" && networkOffline {
+		entries, _ := listCacheEntries(pluginsCache, prefix)
+		for _, entry := range entries {
+			if matchesPluginVersion(entry.version, query) {
+				logrus.Debugf("Latest cached %s version matching %s is: %s", plugin.name, query, entry.version)
+				version = "v" + entry.version
+				break
+			}
+		}
+	} else if query != "" {
+		if cached, ok := findStoredLatestVersion(cacheDir, packagePath+CACHE_ENTRY_DELIMITER+query, packagePath); ok {
+			version = "v" + strings.TrimPrefix(*cached, "v")
+		}
+	}
+
+	if version == PLUGIN_VERSION_LATEST && plugin.release != nil && !networkOffline {
+		latestVersion, err := getCachedLatestBinaryPluginVersion(*plugin.release, cacheDir)
+		if err != nil {
+			logrus.Infof("Latest prebuilt %s version couldn't be resolved, it will be installed with 'go install': %v", plugin.name, err)
+		} else {
+			logrus.Debugf("Latest prebuilt %s version is: %s", plugin.name, *latestVersion)
+			version = "v" + strings.TrimPrefix(*latestVersion, "v")
+		}
+	}
+
+	lockedDir := ""
+	if isFullPluginVersion(version) {
+		lockedDir = filepath.Join(pluginsCache, prefix+strings.TrimPrefix(version, "v"))
+		unlock, err := lockCacheEntry(lockedDir)
+		if err != nil {
+			return "", fmt.Errorf("could not lock %s cache: %v", plugin.name, err)
+		} else {
+			defer unlock()
+		}
+
+		if isCacheEntryComplete(lockedDir, executable) {
+			installed, err := getInstalledGoPackageVersion(goExecutable, lockedDir, executable)
+			if err == nil && strings.TrimPrefix(installed, "v") == strings.TrimPrefix(version, "v") {
+				logrus.Debugf("Package %s found at: %s", plugin.name, lockedDir)
+				markCacheEntryUsed(lockedDir)
+				return lockedDir, nil
+			} else if err != nil {
+				logrus.Infof("Package %s version couldn't be checked, reinstalling: %v", plugin.name, err)
+			} else {
+				logrus.Infof("Package %s version %s doesn't match required version %s, reinstalling", plugin.name, installed, version)
+			}
+		}
+
+		if plugin.release != nil && !networkOffline {
+			logrus.Debugf("Downloading prebuilt package %s version: %s", plugin.name, version)
+			err := installPrebuiltGoPackage(goExecutable, plugin, version, lockedDir, checksums)
+			if err == nil {
+				logrus.Debugf("Prebuilt package %s installed to: %s", plugin.name, lockedDir)
+				markCacheEntryUsed(lockedDir)
+				return lockedDir, nil
+			} else {
+				logrus.Infof("Prebuilt package %s couldn't be downloaded, it will be installed with 'go install': %v", plugin.name, err)
+			}
+		}
+	}
+
+	logrus.Debugf("Package %s is not installed, installing version: %s", plugin.name, version)
+	stagingDir, installed, err := stageGoPackage(goExecutable, pluginsCache, plugin, version)
+	if stagingDir != "" {
+		defer registerCleanup(stagingDir)()
+	}
+	if err != nil {
+		return "", err
+	} else if query != "" && !isFullPluginVersion(version) {
+		storeLatestVersion(cacheDir, packagePath+CACHE_ENTRY_DELIMITER+query, packagePath, installed)
+	}
+
+	pluginDir := filepath.Join(pluginsCache, prefix+strings.TrimPrefix(installed, "v"))
+	if pluginDir != lockedDir {
+		unlock, err := lockCacheEntry(pluginDir)
+		if err != nil {
+			return "", fmt.Errorf("could not lock %s cache: %v", plugin.name, err)
+		} else {
+			defer unlock()
+		}
+
+		if isCacheEntryComplete(pluginDir, executable) {
+			logrus.Debugf("Package %s was installed concurrently to: %s", plugin.name, pluginDir)
+			markCacheEntryUsed(pluginDir)
+			return pluginDir, nil
+		}
+	}
+
+	err = commitCacheEntry(stagingDir, pluginDir)
+	if err != nil {
+		return "", fmt.Errorf("could not install package %s: %v", plugin.name, err)
+	}
+
+	logrus.Debugf("Package %s installed to: %s", plugin.name, pluginDir)
+	markCacheEntryUsed(pluginDir)
+	return pluginDir, nil
+}
@@ -0,0 +1,351 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	LOCK_FILE_NAME         = "protogo.lock"
+	ARCHIVE_INFO_FILE_NAME = ".protogo-archive.yaml"
+	LOCK_FILE_HEADER       = "# This file is generated by 'protogo lock' command, do not edit it manually!\n"
+)
+
+// Information about a downloaded archive.
+// Is stored both in the cache directory (next to the archive contents) and in the lock file.
+// For Google APIs library, the version is the library revision.
+// Checksums map release asset names for all the supported platforms to their SHA-256 digests (only recorded in the lock file).
+type ArchiveInfo struct {
+	Version   string            `yaml:"version"`
+	Asset     string            `yaml:"asset"`
+	SHA256    string            `yaml:"sha256"`
+	Checksums map[string]string `yaml:"checksums,omitempty"`
+}
+
+// Information about an installed GO plugin.
+// Asset name and SHA-256 checksums are only recorded if the plugin was downloaded as a prebuilt release asset.
+type PluginInfo struct {
+	Module    string            `yaml:"module"`
+	Version   string            `yaml:"version"`
+	Asset     string            `yaml:"asset,omitempty"`
+	SHA256    string            `yaml:"sha256,omitempty"`
+	Checksums map[string]string `yaml:"checksums,omitempty"`
+}
+
+// Lock file contents, pinning exact versions of all the downloaded and installed tools.
+type Lock struct {
+	Protoc        *ArchiveInfo            `yaml:"protoc,omitempty"`
+	Flatc         *ArchiveInfo            `yaml:"flatc,omitempty"`
+	GoogleAPIs    *ArchiveInfo            `yaml:"googleapis,omitempty"`
+	Plugins       map[string]PluginInfo   `yaml:"plugins,omitempty"`
+	BinaryPlugins map[string]*ArchiveInfo `yaml:"binary_plugins,omitempty"`
+}
+
+// Read archive info stored in the given cache directory.
+//
+// Accept cache directory path.
+// Return archive info pointer (or nil if it can not be read).
+func readArchiveInfo(dir string) *ArchiveInfo {
+	data, err := os.ReadFile(filepath.Join(dir, ARCHIVE_INFO_FILE_NAME))
+	if err != nil {
+		return nil
+	}
+
+	var info ArchiveInfo
+	err = yaml.Unmarshal(data, &info)
+	if err != nil {
+		logrus.Warnf("Archive info in %s is corrupted: %v", dir, err)
+		return nil
+	}
+
+	return &info
+}
+
+// Write archive info to the given cache directory.
+//
+// Accept cache directory path and archive info pointer.
+// Return error.
+func writeArchiveInfo(dir string, info *ArchiveInfo) error {
+	data, err := yaml.Marshal(info)
+	if err != nil {
+		return fmt.Errorf("error serializing archive info: %v", err)
+	}
+
+	err = os.WriteFile(filepath.Join(dir, ARCHIVE_INFO_FILE_NAME), data, 0644)
+	if err != nil {
+		return fmt.Errorf("error writing archive info to %s: %v", dir, err)
+	}
+
+	return nil
+}
+
+// Read lock file.
+// If the file does not exist, return empty lock.
+//
+// Accept lock file path.
+// Return lock pointer and error.
+func readLockFile(path string) (*Lock, error) {
+	var lock Lock
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &lock, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("error reading lock file %s: %v", path, err)
+	}
+
+	err = yaml.Unmarshal(data, &lock)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing lock file %s: %v", path, err)
+	}
+
+	return &lock, nil
+}
+
+// Write lock file.
+//
+// Accept lock file path and lock pointer.
+// Return error.
+func writeLockFile(path string, lock *Lock) error {
+	data, err := yaml.Marshal(lock)
+	if err != nil {
+		return fmt.Errorf("error serializing lock: %v", err)
+	}
+
+	err = os.WriteFile(path, append([]byte(LOCK_FILE_HEADER), data...), 0644)
+	if err != nil {
+		return fmt.Errorf("error writing lock file %s: %v", path, err)
+	}
+
+	return nil
+}
+
+// Choose archive version to use, taking lock file into account.
+// Locked version is used if a version alias (e.g. "latest"), exactly the locked version or a constraint, satisfied by the locked version, is requested.
+// If another version is requested explicitly, the lock is ignored (with a warning).
+//
+// Accept tool name (for logging), requested version and locked archive info pointer (or nil if not locked).
+// Return version to use.
+func resolveLockedVersion(name, requested string, locked *ArchiveInfo) string {
+	if locked == nil || requested == "local" {
+		return requested
+	}
+
+	if slices.Contains(versionAliases, requested) || strings.TrimPrefix(requested, "v") == strings.TrimPrefix(locked.Version, "v") || (isVersionConstraint(requested) && matchesVersionExpression(locked.Version, requested)) {
+		logrus.Debugf("Using %s version from lock file: %s", name, locked.Version)
+		return locked.Version
+	}
+
+	logrus.Warnf("Requested %s version %s doesn't match locked version %s, lock is ignored (run 'protogo lock' to update it)", name, requested, locked.Version)
+	return requested
+}
+
+// Check whether lock file pins anything.
+//
+// Accept lock pointer.
+// Return true if the lock is empty (e.g. lock file doesn't exist).
+func isLockEmpty(lock *Lock) bool {
+	return lock.Protoc == nil && lock.Flatc == nil && lock.GoogleAPIs == nil && len(lock.Plugins) == 0 && len(lock.BinaryPlugins) == 0
+}
+
+// Collect all the known archive checksums.
+// Checksums from the lock file (including the ones of the other platforms assets) take precedence over the configured ones.
+//
+// Accept configuration pointer and lock pointer.
+// Return asset name to SHA-256 digest map.
+func collectChecksums(config *Config, lock *Lock) map[string]string {
+	checksums := make(map[string]string, len(config.Checksums)+3)
+	for asset, digest := range config.Checksums {
+		checksums[asset] = digest
+	}
+
+	infos := []*ArchiveInfo{lock.Protoc, lock.Flatc, lock.GoogleAPIs}
+	for _, info := range lock.BinaryPlugins {
+		infos = append(infos, info)
+	}
+
+	for _, info := range infos {
+		if info == nil {
+			continue
+		}
+		for asset, digest := range info.Checksums {
+			checksums[asset] = digest
+		}
+		if info.SHA256 != "" {
+			checksums[info.Asset] = info.SHA256
+		}
+	}
+	for _, plugin := range lock.Plugins {
+		for asset, digest := range plugin.Checksums {
+			checksums[asset] = digest
+		}
+		if plugin.SHA256 != "" {
+			checksums[plugin.Asset] = plugin.SHA256
+		}
+	}
+
+	return checksums
+}
+
+// Run "protogo lock" command.
+// Resolve the configured versions (ignoring existing lock), install the tools and record their exact versions and checksums to the lock file.
+// Checksums of the release assets for all the supported platforms are recorded, so that the lock file verifies downloads on any platform.
+// Plugin versions are either configured or derived from the current GO module requirements.
+// If no compilers are specified, the compilers already present in the lock file are refreshed.
+//
+// Accept configuration pointer and command arguments (compiler names).
+// Return error.
+func lockCommand(config *Config, args []string) error {
+	lockFile := filepath.Join(config.ProjectDir, LOCK_FILE_NAME)
+	lock, err := readLockFile(lockFile)
+	if err != nil {
+		return fmt.Errorf("could not read lock file: %v", err)
+	}
+
+	compilers := args
+	if len(compilers) == 0 {
+		if lock.Protoc != nil {
+			compilers = append(compilers, PROTOC_EXECUTABLE)
+		}
+		if lock.Flatc != nil {
+			compilers = append(compilers, FLATC_EXECUTABLE)
+		}
+	}
+	if len(compilers) == 0 {
+		return fmt.Errorf("no compilers to lock specified (use 'protogo lock %s' or 'protogo lock %s')", PROTOC_EXECUTABLE, FLATC_EXECUTABLE)
+	}
+
+	cacheDir, err := getProtogoCacheDir(config.Cache)
+	if err != nil {
+		return fmt.Errorf("could not find or create cache directory: %v", err)
+	}
+
+	// "latest" versions are always resolved from network, so that outdated reused versions are never locked.
+	configureLatestCache(config.LatestTTL, true)
+	checksums := collectChecksums(config, lock)
+
+	for _, compiler := range compilers {
+		switch compiler {
+		case PROTOC_EXECUTABLE:
+			if config.Protoc.Version == "local" {
+				return fmt.Errorf("local %s version can not be locked", PROTOC_EXECUTABLE)
+			}
+
+			logrus.Debugf("Locking %s version: %s", PROTOC_EXECUTABLE, config.Protoc.Version)
+			_, lock.Protoc, err = ensureProtoc(config.Protoc.Version, config.Protoc.Mirror, *cacheDir, checksums)
+			if err != nil {
+				return fmt.Errorf("could not lock %s: %v", PROTOC_EXECUTABLE, err)
+			} else if lock.Protoc == nil {
+				return fmt.Errorf("could not lock %s: archive info not found", PROTOC_EXECUTABLE)
+			}
+			lock.Protoc.Checksums = getProtocChecksums(*lock.Protoc, config.Protoc.Mirror)
+
+			if slices.Contains(config.Protoc.Include, "googleapis") {
+				revision, err := getLatestGoogleAPIsRevision(config.GoogleAPIs.Mirror)
+				if err != nil {
+					return fmt.Errorf("could not resolve latest Google APIs library revision: %v", err)
+				}
+
+				logrus.Debugf("Locking Google APIs library revision: %s", *revision)
+				_, lock.GoogleAPIs, err = ensureGoogleAPIs(*revision, config.GoogleAPIs.Mirror, *cacheDir, checksums)
+				if err != nil {
+					return fmt.Errorf("could not lock Google APIs library: %v", err)
+				} else if lock.GoogleAPIs == nil {
+					return errors.New("could not lock Google APIs library: archive info not found")
+				}
+			} else {
+				lock.GoogleAPIs = nil
+			}
+
+			goExec, err := getGoExecutable(config.GoExecutable)
+			if err != nil {
+				return fmt.Errorf("could not find go executable: %v", err)
+			}
+
+			requirements, err := getGoModRequirements(*goExec)
+			if err != nil {
+				logrus.Warnf("Could not read GO module requirements, plugin versions will not be derived from them: %v", err)
+			}
+
+			toolPlugins, err := getProtocToolPlugins(*goExec)
+			if err != nil {
+				logrus.Warnf("Could not read GO module tools, all plugins will be locked: %v", err)
+			}
+
+			plugins := getProtocPlugins(config)
+			lock.Plugins = make(map[string]PluginInfo, len(plugins))
+			for _, plugin := range plugins {
+				if _, ok := toolPlugins[plugin.name]; ok {
+					logrus.Debugf("Package %s is declared as a tool in go.mod, its version is locked by go.sum", plugin.name)
+					continue
+				}
+
+				version := resolvePluginVersion(plugin, config, &Lock{}, requirements)
+				logrus.Debugf("Locking package %s version: %s", plugin.name, version)
+				pluginDir, err := ensureGoPackageInstalled(*goExec, *cacheDir, plugin, version, checksums)
+				if err != nil {
+					return fmt.Errorf("could not install package %s: %v", plugin.name, err)
+				}
+
+				module, version, err := getGoPackageVersion(*goExec, filepath.Join(pluginDir, getExecutableName(plugin.name)))
+				if err != nil {
+					return fmt.Errorf("could not get package %s version: %v", plugin.name, err)
+				}
+				if info := readArchiveInfo(pluginDir); info != nil {
+					var checksums map[string]string
+					if plugin.release != nil {
+						checksums = getBinaryPluginChecksums(*plugin.release, *info)
+					}
+					lock.Plugins[plugin.name] = PluginInfo{Module: module, Version: "v" + strings.TrimPrefix(info.Version, "v"), Asset: info.Asset, SHA256: info.SHA256, Checksums: checksums}
+				} else {
+					lock.Plugins[plugin.name] = PluginInfo{Module: module, Version: version}
+				}
+			}
+
+			lock.BinaryPlugins = make(map[string]*ArchiveInfo, len(config.Protoc.BinaryPlugins))
+			for _, plugin := range config.Protoc.BinaryPlugins {
+				logrus.Debugf("Locking prebuilt plugin %s version: %s", plugin.Name, getBinaryPluginVersion(plugin))
+				_, info, err := ensureBinaryPlugin(plugin, getBinaryPluginVersion(plugin), *cacheDir, checksums)
+				if err != nil {
+					return fmt.Errorf("could not lock prebuilt plugin %s: %v", plugin.Name, err)
+				} else if info == nil {
+					return fmt.Errorf("could not lock prebuilt plugin %s: archive info not found", plugin.Name)
+				}
+				info.Checksums = getBinaryPluginChecksums(plugin, *info)
+				lock.BinaryPlugins[plugin.Name] = info
+			}
+
+		case FLATC_EXECUTABLE:
+			if config.Flatc.Version == "local" {
+				return fmt.Errorf("local %s version can not be locked", FLATC_EXECUTABLE)
+			}
+
+			logrus.Debugf("Locking %s version: %s", FLATC_EXECUTABLE, config.Flatc.Version)
+			_, lock.Flatc, err = ensureFlatc(config.Flatc.Version, config.Flatc.Distro, config.Flatc.Mirror, *cacheDir, checksums)
+			if err != nil {
+				return fmt.Errorf("could not lock %s: %v", FLATC_EXECUTABLE, err)
+			} else if lock.Flatc == nil {
+				return fmt.Errorf("could not lock %s: archive info not found", FLATC_EXECUTABLE)
+			}
+			lock.Flatc.Checksums = getFlatcChecksums(*lock.Flatc, config.Flatc.Mirror)
+
+		default:
+			return fmt.Errorf("unknown compiler requested: %s", compiler)
+		}
+	}
+
+	err = writeLockFile(lockFile, lock)
+	if err != nil {
+		return fmt.Errorf("could not write lock file: %v", err)
+	}
+
+	logrus.Infof("Lock file written: %s", lockFile)
+	return nil
+}
@@ -11,7 +11,6 @@ import (
 	"os/exec"
 	"path/filepath"
 	"slices"
-	"strings"
 
 	"github.com/sirupsen/logrus"
 )
@@ -23,6 +22,30 @@ const (
 	PROTOC_GEN_GO_GRPC_PREFIX  = "google.golang.org/grpc/cmd"
 )
 
+// GO package (command), installable with "go install".
+// Its default version can be derived from the current GO module requirements.
+// If the package is also published as prebuilt release assets, they are downloaded instead of compiling the package.
+type goPackage struct {
+	prefix  string
+	name    string
+	derive  func(requirements map[string]string) (string, bool)
+	release *BinaryPluginConfig
+}
+
+// Built-in GO plugins, always required for protobuf compiler.
+// More plugins can be added in configuration (see "getProtocPlugins").
+var protocGoPlugins = []goPackage{
+	{prefix: PROTOC_GEN_GO_PREFIX, name: PROTOC_GEN_GO_PACKAGE, derive: deriveProtocGenGoVersion, release: &protocGenGoRelease},
+	{prefix: PROTOC_GEN_GO_GRPC_PREFIX, name: PROTOC_GEN_GO_GRPC_PACKAGE, derive: deriveProtocGenGoGrpcVersion},
+}
+
+// "protogo" commands, run instead of compiler and GO if the first argument matches command name.
+var protogoCommands = map[string]func(config *Config, args []string) error{
+	"lock":     lockCommand,
+	"cache":    cacheCommand,
+	"versions": versionsCommand,
+}
+
 // `protogo` package help string.
 const HELP_TEXT = `    'protogo' is an automatization tool for Go + protobuf/flatbuffers + gRPC builds!
 You can run it with the same arguments as 'go' executable, followed by '--' flag and then compiler name ('protoc' or 'flatc') and its arguments.
@@ -30,48 +53,133 @@ Protogo will handle everything else, including compiler binaries installation, i
 Use official gRPC installation guide as reference for protobuf: https://grpc.io/docs/languages/go/quickstart/#prerequisites.
 Use official gRPC installation guide as reference for flatbuffers: https://flatbuffers.dev/languages/go/.
 Inspired by similar projects for other languages, including https://pypi.org/project/protoc-exe/ and https://crates.io/crates/protoc-prebuilt/.
-You can additionally control it with the following environment variables:
-  - PROTOGO_GO_EXECUTABLE: define 'go' executable to use, default: go
-  - PROTOGO_PROTOC_VERSION: define 'protoc' version to use, should match protobuf release tags, default: latest
+You can additionally control it with the following environment variables (or flags, placed before all the other arguments):
+  - PROTOGO_GO_EXECUTABLE (--go-executable=...): define 'go' executable to use, default: go
+  - PROTOGO_PROTOC_VERSION (--protoc-version=...): define 'protoc' version to use, should match protobuf release tags, default: latest
       NB! If 'local' is specified as 'protoc' version, local installation will be used
-  - PROTOGO_FLATC_VERSION: defins 'flatc' version to use, should match protobuf release tags, default: latest
+      NB! Version constraints are also supported, e.g. '~25.1', '^24', '25.x' or '>=23,<26' (cached versions are preferred)
+      NB! Version aliases 'latest-rc' (including pre-releases) and 'previous-major' (the latest release of the previous major version) are also supported
+  - PROTOGO_PROTOC_GEN_GO_VERSION (--protoc-gen-go-version=...): define 'protoc-gen-go' version to use, default: derived from 'google.golang.org/protobuf' version in go.mod
+  - PROTOGO_PROTOC_GEN_GO_GRPC_VERSION (--protoc-gen-go-grpc-version=...): define 'protoc-gen-go-grpc' version to use, default: derived from 'google.golang.org/grpc' version in go.mod
+      NB! Plugins are installed to '[CACHE]/plugins/[NAME]@[VERSION]' directories and passed to compiler with '--plugin=[NAME]=[PATH]' arguments
+      NB! Other plugins are looked up in compiler PATH, with GOBIN appended to it
+      NB! Installed plugin version is read from its build info ('go version -m') and the plugin is reinstalled if it doesn't match
+  - PROTOGO_PROTOC_PREBUILT_PLUGINS (--protoc-prebuilt-plugins[=...]): download prebuilt 'protoc-gen-go' from protobuf-go releases instead of 'go install', default: true
+      NB! 'go install' is still used if there is no prebuilt archive for the current platform
+  - PROTOGO_FLATC_VERSION (--flatc-version=...): defins 'flatc' version to use, should match protobuf release tags, default: latest
       NB! If 'local' is specified as 'flatc' version, local installation will be used
-  - PROTOGO_PROTOC_INCLUDE: comma-separated list of "special" includes, can include 'standard' (for standard types) and 'googleapis'
-  - PROTOGO_FLATC_DISTRO: select distribution of 'flatc' for linux (can be either 'g++' or 'clang', default 'g++')
-  - PROTOGO_CACHE: define cache directory, where 'protobuf' executables will be stored, default: ~/.cache/protogo
+      NB! Version constraints are also supported, e.g. '~25.1', '^24', '25.x' or '>=23,<26' (cached versions are preferred)
+      NB! Version aliases 'latest-rc' (including pre-releases) and 'previous-major' (the latest release of the previous major version) are also supported
+  - PROTOGO_PROTOC_INCLUDE (--protoc-include=...): comma-separated list of "special" includes, can include 'standard' (for standard types) and 'googleapis'
+  - PROTOGO_FLATC_DISTRO (--flatc-distro=...): select distribution of 'flatc' for linux (can be either 'g++' or 'clang', default 'g++')
+      NB! Compiler release assets are discovered with GitHub API, the best matching asset for the current platform is chosen
+  - PROTOGO_CACHE (--cache=...): define cache directory, where 'protobuf' executables will be stored, default: ~/.cache/protogo
+  - PROTOGO_PROTOC_MIRROR (--protoc-mirror=...): base URL of 'protoc' releases mirror, default: GitHub releases
+  - PROTOGO_FLATC_MIRROR (--flatc-mirror=...): base URL of 'flatc' releases mirror, default: GitHub releases
+  - PROTOGO_GOOGLEAPIS_MIRROR (--googleapis-mirror=...): base URL of Google APIs library archives mirror, default: GitHub archives
+      NB! Mirrors should host '[BASE]/v[VERSION]/[ASSET]' files for compilers, '[BASE]/[REVISION].zip' for Google APIs library
+      and '[BASE]/index.json' file with '{"latest": "[VERSION]", "versions": ["[VERSION]", ...]}' contents for version resolution
+  - PROTOGO_OFFLINE (--offline): never access network, use only cached compilers and GO module cache (GOPROXY=off)
+      NB! In offline mode 'latest' version is resolved to the latest cached version
+  - PROTOGO_LATEST_TTL (--latest-ttl=...): time, during which resolved 'latest' versions (including GO plugins ones) are reused without GitHub API requests and 'go install' runs (GO duration format), default: 24h
+      NB! Resolved versions are stored in '[CACHE]/latest.json' file, '0s' disables reusing, 'protogo lock' never reuses them
+  - PROTOGO_REFRESH (--refresh): resolve 'latest' versions from network, ignoring the reused ones
+  - PROTOGO_CONNECT_TIMEOUT (--connect-timeout=...): network connection timeout (GO duration format), default: 30s
+  - PROTOGO_TIMEOUT (--timeout=...): total network request timeout, including download (GO duration format), default: 10m
+  - PROTOGO_RETRIES (--retries=...): number of retries for transient network errors and 5xx responses, default: 3
+  - PROTOGO_RATE_LIMIT_WAIT (--rate-limit-wait=...): maximum time to wait for GitHub API rate limit reset (GO duration format), default: 0s (fail immediately)
   - PROTOGO_GITHUB_BEARER_TOKEN: GitHub authentication token for API requests (release assets retrieval)
-  - PROTOGO_LOG_LEVEL: define logging level, the levels match 'logrus' ones`
+      NB! If not set, 'GITHUB_TOKEN' environment variable and then netrc file ('api.github.com' or 'github.com' machine password) are used
+      NB! GitHub token is only sent to 'api.github.com' and 'github.com', credentials for other hosts (e.g. mirrors) are set in user config file only
+  - PROTOGO_LOG_LEVEL (--log-level=...): define logging level, the levels match 'logrus' ones
+  - PROTOGO_REQUIRE_CHECKSUMS (--require-checksums[=...]): reject archives, whose expected SHA-256 digest is unknown, default: false
+      NB! Checksums are always required if 'protogo.lock' file exists
+The same settings can be stored in 'protogo.yaml' config file, searched from the current directory up to the GO module root,
+and in the user config file ('~/.config/protogo/protogo.yaml' on linux).
+Precedence is the following: flags > environment variables > project config file > user config file.
+Config file example:
+  go_executable: go
+  cache: .protogo
+  log_level: INFO
+  offline: false
+  latest_ttl: 24h
+  network:
+    connect_timeout: 30s
+    timeout: 10m
+    retries: 3
+    rate_limit_wait: 5m
+    credentials: # user config file only
+      mirror.example.com: ${MIRROR_TOKEN}
+  protoc:
+    version: 25.1
+    include: [standard, googleapis]
+    mirror: https://mirror.example.com/protobuf
+    plugins:
+      - name: protoc-gen-go
+        version: v1.34.2
+      - package: connectrpc.com/connect/cmd/protoc-gen-connect-go
+        version: latest
+  googleapis:
+    mirror: https://mirror.example.com/api-common-protos
+  flatc:
+    version: latest
+    distro: clang
+  checksums:
+    protoc-25.1-linux-x86_64.zip: sha256:...
+Any GO-installable 'protoc' plugin can be added to 'protoc.plugins' config section ('package' is the full GO package path of the plugin command).
+Prebuilt (non-GO) 'protoc' plugins can be downloaded from GitHub releases, configured in 'protoc.binary_plugins' config section.
+Plugins, declared as tools in go.mod ('go get -tool ...', GO 1.24+), are built with 'go tool' and used instead of the installed ones.
+Additional commands (run instead of compiler and GO):
+  - protogo lock [protoc] [flatc]: resolve configured versions and pin them in 'protogo.lock' file, placed next to 'protogo.yaml' (or 'go.mod')
+      NB! Lock file records compiler versions, archive names and SHA-256 checksums (for all the supported platforms), Google APIs revision and plugin versions
+      Without arguments, the compilers already present in lock file are refreshed
+  - protogo cache list: list all the cached compilers, libraries and plugins with their sizes and last usage times
+  - protogo cache prune [--keep=N] [--older-than=AGE]: remove cached versions except for N most recently used ones of each tool
+      and/or the ones not used for AGE (e.g. '72h' or '30d'), versions pinned in lock file are never removed
+  - protogo cache rm [TOOL]@[VERSION]...: remove specific cached versions, e.g. 'protoc@25.1', 'flatc@24.3.25', 'googleapis@main' or 'protoc-gen-go@v1.34.2'
+  - protogo cache clean: remove all cached compilers, libraries and plugins (every entry is removed holding its lock)
+  - protogo versions protoc|flatc: list all the available compiler release versions, marking cached and locked versions and version aliases
+      NB! In offline mode only cached and locked versions are listed`
 
-func init() {
-	var unparsedLevel string
+func main() {
+	var err error
+	handleInterruptions()
 
-	if value, ok := os.LookupEnv("PROTOGO_LOG_LEVEL"); ok {
-		unparsedLevel = value
-	} else {
-		unparsedLevel = "WARN"
+	config, args, err := loadConfig(os.Args[1:])
+	if err != nil {
+		logrus.Fatalf("Could not load configuration: %v", err)
 	}
 
-	level, err := logrus.ParseLevel(unparsedLevel)
+	level, err := logrus.ParseLevel(config.LogLevel)
 	if err != nil {
-		logrus.Fatalf("Error parsing log level environmental variable: %v", unparsedLevel)
+		logrus.Fatalf("Error parsing log level configuration: %v", config.LogLevel)
 	}
-
 	logrus.SetLevel(level)
-}
-
-func main() {
-	var err error
+	configureNetwork(config.Offline, &config.Network)
+	configureLatestCache(config.LatestTTL, config.Refresh)
+	configureChecksums(config.RequireChecksums)
+
+	if len(args) > 0 {
+		if command, ok := protogoCommands[args[0]]; ok {
+			logrus.Debugf("Running protogo command %s with arguments: %v", args[0], args[1:])
+			err = command(config, args[1:])
+			if err != nil {
+				logrus.Fatalf("Command %s failed: %v", args[0], err)
+			}
+			os.Exit(0)
+		}
+	}
 
 	argsDelim := -1
-	argLen := len(os.Args)
-	for i := 1; i < argLen; i++ {
-		if os.Args[i] == "--" {
+	argLen := len(args)
+	for i := 0; i < argLen; i++ {
+		if args[i] == "--" {
 			argsDelim = i
 			break
 		}
 	}
 
-	logrus.Debugf("Running protogo (delim: %d) with arguments: %v", argsDelim, os.Args)
+	logrus.Debugf("Running protogo (delim: %d) with arguments: %v", argsDelim, args)
 	if argsDelim == -1 {
 		fmt.Println(HELP_TEXT)
 		os.Exit(0)
@@ -79,14 +187,14 @@ func main() {
 
 	var goArgs []string
 	if argsDelim > 0 {
-		goArgs = os.Args[1:argsDelim]
+		goArgs = args[:argsDelim]
 		logrus.Debugf("GO command arguments parsed: %v", goArgs)
 	}
 
 	var compiler string
 	compilerNameArg := argsDelim + 1
 	if compilerNameArg < argLen {
-		compiler = os.Args[compilerNameArg]
+		compiler = args[compilerNameArg]
 		logrus.Debugf("Compiler command parsed: %v", compiler)
 	} else {
 		logrus.Debug("Compiler command not found, so will be ignored!")
@@ -95,7 +203,7 @@ func main() {
 	var compilerArgs []string
 	compilerArgStart := compilerNameArg + 1
 	if compilerArgStart < argLen {
-		compilerArgs = os.Args[compilerArgStart:argLen]
+		compilerArgs = args[compilerArgStart:argLen]
 		logrus.Debugf("Compiler command arguments parsed: %v", compilerArgs)
 	}
 
@@ -107,14 +215,13 @@ func main() {
 
 	includeProtoStandard := false
 	includeProtoGoogleAPIs := false
-	if value, ok := os.LookupEnv("PROTOGO_PROTOC_INCLUDE"); ok && compiler == PROTOC_EXECUTABLE {
-		includes := strings.Split(value, ",")
-		includeProtoStandard = slices.Contains(includes, "standard")
-		includeProtoGoogleAPIs = slices.Contains(includes, "googleapis")
+	if compiler == PROTOC_EXECUTABLE {
+		includeProtoStandard = slices.Contains(config.Protoc.Include, "standard")
+		includeProtoGoogleAPIs = slices.Contains(config.Protoc.Include, "googleapis")
 	}
 
 	logrus.Debug("Checking cache directory location...")
-	protogoCache, err := getProtogoCacheDir("PROTOGO_CACHE")
+	protogoCache, err := getProtogoCacheDir(config.Cache)
 	if err != nil {
 		logrus.Fatalf("Could not find or create cache directory: %v", err)
 	} else {
@@ -122,89 +229,114 @@ func main() {
 	}
 
 	logrus.Debug("Checking GO executable...")
-	goExec, err := getGoExecutable("PROTOGO_GO_EXECUTABLE")
+	goExec, err := getGoExecutable(config.GoExecutable)
 	if err != nil {
 		logrus.Fatalf("Could not find go executable: %v", err)
 	} else {
 		logrus.Debugf("GO executable found: %s", *goExec)
 	}
 
-	logrus.Debug("Checking GO binary location...")
-	goBin, err := getGoBinaryLocation(*goExec)
+	logrus.Debug("Reading lock file...")
+	lock, err := readLockFile(filepath.Join(config.ProjectDir, LOCK_FILE_NAME))
 	if err != nil {
-		logrus.Fatalf("Could not find go binary location: %v", err)
-	} else {
-		logrus.Debugf("GO binary location found: %s", *goBin)
+		logrus.Fatalf("Could not read lock file: %v", err)
 	}
+	checksums := collectChecksums(config, lock)
+	configureChecksums(config.RequireChecksums || !isLockEmpty(lock))
 
 	var compilerExecutable string
+	var pluginArgs []string
+	var goBin *string
 	switch compiler {
 	case PROTOC_EXECUTABLE:
 		logrus.Debug("Extracting required compiler version...")
-		protocTag, protocCache, shouldDownload, err := getProtocCache("PROTOGO_PROTOC_VERSION", *protogoCache)
+		protocVersion := resolveLockedVersion(PROTOC_EXECUTABLE, config.Protoc.Version, lock.Protoc)
+		compilerExecutable, _, err = ensureProtoc(protocVersion, config.Protoc.Mirror, *protogoCache, checksums)
 		if err != nil {
-			logrus.Fatalf("Could not find or load protoc executable: %v", err)
-		} else if protocCache != nil {
-			logrus.Debugf("Protoc version requested: %s, cache location: %s, will be downloaded: %t", *protocTag, *protocCache, shouldDownload)
-		} else {
-			logrus.Debugf("Protoc version requested: %s, system default, will not be downloaded", *protocTag)
+			logrus.Fatalf("Could not ensure protoc executable: %v", err)
+		}
+
+		requirements, err := getGoModRequirements(*goExec)
+		if err != nil {
+			logrus.Warnf("Could not read GO module requirements, plugin versions will not be derived from them: %v", err)
+		}
+
+		toolPlugins, err := getProtocToolPlugins(*goExec)
+		if err != nil {
+			logrus.Warnf("Could not read GO module tools, all plugins will be installed: %v", err)
+		}
+
+		toolNames := make([]string, 0, len(toolPlugins))
+		for name := range toolPlugins {
+			toolNames = append(toolNames, name)
+		}
+		slices.Sort(toolNames)
+
+		for _, name := range toolNames {
+			if plugin := findPluginConfig(config, name); plugin != nil && plugin.Version != PLUGIN_VERSION_AUTO {
+				logrus.Warnf("Plugin %s is declared as a tool in go.mod, configured version %s is ignored", name, plugin.Version)
+			}
+
+			pluginExec, err := getGoToolExecutable(*goExec, toolPlugins[name])
+			if err != nil {
+				logrus.Fatalf("Could not build GO tool %s: %v", name, err)
+			} else {
+				logrus.Debugf("Plugin %s is built from GO module tool %s: %s", name, toolPlugins[name], pluginExec)
+			}
+			pluginArg, err := makePluginArgument(name, pluginExec)
+			if err != nil {
+				logrus.Fatalf("Could not resolve plugin %s: %v", name, err)
+			}
+			pluginArgs = append(pluginArgs, pluginArg)
 		}
 
-		if shouldDownload {
-			logrus.Debug("Downloading protoc executable...")
-			protocExec, err := downloadProtocVersion(*protocTag, *protocCache)
+		for _, plugin := range getProtocPlugins(config) {
+			if _, ok := toolPlugins[plugin.name]; ok {
+				logrus.Debugf("Package %s is declared as a tool in go.mod, installation skipped!", plugin.name)
+				continue
+			}
+
+			pluginDir, err := ensureGoPackageInstalled(*goExec, *protogoCache, plugin, resolvePluginVersion(plugin, config, lock, requirements), checksums)
 			if err != nil {
-				logrus.Fatalf("Could not download or extract protoc: %v", err)
+				logrus.Fatalf("Could not find or install package %s: %v", plugin.name, err)
+			} else {
+				logrus.Debugf("Package %s found or installed successfully!", plugin.name)
 			}
-			compilerExecutable = *protocExec
-			logrus.Debugf("Protoc executable downloaded to: %s", compilerExecutable)
-		} else if protocCache != nil {
-			compilerExecutable = filepath.Join(*protocCache, "bin", getExecutableName(PROTOC_EXECUTABLE))
-			logrus.Debugf("Protoc executable found at: %s", compilerExecutable)
-		} else {
-			compilerExecutable = PROTOC_EXECUTABLE
-			logrus.Debugf("Protoc executable found at: %s", compilerExecutable)
+			pluginArg, err := makePluginArgument(plugin.name, filepath.Join(pluginDir, getExecutableName(plugin.name)))
+			if err != nil {
+				logrus.Fatalf("Could not resolve plugin %s: %v", plugin.name, err)
+			}
+			pluginArgs = append(pluginArgs, pluginArg)
 		}
 
-		err = ensureGoPackageInstalled(*goExec, *goBin, PROTOC_GEN_GO_PREFIX, PROTOC_GEN_GO_PACKAGE)
-		if err != nil {
-			logrus.Fatalf("Could not find or install package %s: %v", PROTOC_GEN_GO_PACKAGE, err)
-		} else {
-			logrus.Debugf("Package %s found or installed successfully!", PROTOC_GEN_GO_PACKAGE)
+		for _, plugin := range config.Protoc.BinaryPlugins {
+			pluginVersion := resolveLockedVersion(plugin.Name, getBinaryPluginVersion(plugin), lock.BinaryPlugins[plugin.Name])
+			pluginExec, _, err := ensureBinaryPlugin(plugin, pluginVersion, *protogoCache, checksums)
+			if err != nil {
+				logrus.Fatalf("Could not ensure prebuilt plugin %s: %v", plugin.Name, err)
+			} else {
+				logrus.Debugf("Prebuilt plugin %s found or downloaded successfully!", plugin.Name)
+			}
+			pluginArg, err := makePluginArgument(plugin.Name, pluginExec)
+			if err != nil {
+				logrus.Fatalf("Could not resolve plugin %s: %v", plugin.Name, err)
+			}
+			pluginArgs = append(pluginArgs, pluginArg)
 		}
 
-		err = ensureGoPackageInstalled(*goExec, *goBin, PROTOC_GEN_GO_GRPC_PREFIX, PROTOC_GEN_GO_GRPC_PACKAGE)
+		goBin, err = getGoBinaryLocation(*goExec)
 		if err != nil {
-			logrus.Fatalf("Could not find or install package %s: %v", PROTOC_GEN_GO_GRPC_PACKAGE, err)
+			logrus.Warnf("Could not find GO binary location, unmanaged plugins will be looked up in PATH only: %v", err)
 		} else {
-			logrus.Debugf("Package %s found or installed successfully!", PROTOC_GEN_GO_GRPC_PACKAGE)
+			logrus.Debugf("GO binary location found: %s", *goBin)
 		}
 
 	case FLATC_EXECUTABLE:
 		logrus.Debug("Extracting required compiler version...")
-		flatcTag, flatcCache, shouldDownload, err := getFlatcCache("PROTOGO_FLATC_VERSION", *protogoCache)
+		flatcVersion := resolveLockedVersion(FLATC_EXECUTABLE, config.Flatc.Version, lock.Flatc)
+		compilerExecutable, _, err = ensureFlatc(flatcVersion, config.Flatc.Distro, config.Flatc.Mirror, *protogoCache, checksums)
 		if err != nil {
-			logrus.Fatalf("Could not find or load flatc executable: %v", err)
-		} else if flatcCache != nil {
-			logrus.Debugf("Flatc version requested: %s, cache location: %s, will be downloaded: %t", *flatcTag, *flatcCache, shouldDownload)
-		} else {
-			logrus.Debugf("Flatc version requested: %s, system default, will not be downloaded", *flatcTag)
-		}
-
-		if shouldDownload {
-			logrus.Debug("Downloading flatc executable...")
-			flatcExec, err := downloadFlatcVersion(*flatcTag, *flatcCache)
-			if err != nil {
-				logrus.Fatalf("Could not download or extract flatc: %v", err)
-			}
-			compilerExecutable = *flatcExec
-			logrus.Debugf("Flatc executable downloaded to: %s", compilerExecutable)
-		} else if flatcCache != nil {
-			compilerExecutable = filepath.Join(*flatcCache, getExecutableName(FLATC_EXECUTABLE))
-			logrus.Debugf("Flatc executable found at: %s", compilerExecutable)
-		} else {
-			compilerExecutable = FLATC_EXECUTABLE
-			logrus.Debugf("Flatc executable found at: %s", compilerExecutable)
+			logrus.Fatalf("Could not ensure flatc executable: %v", err)
 		}
 
 	default:
@@ -214,31 +346,18 @@ func main() {
 	var googleAPIsPath string
 	if includeProtoGoogleAPIs {
 		logrus.Debug("Extracting required Google APIs version...")
-		googleAPIsCache, shouldDownload, err := getGoogleAPIsCache(*protogoCache)
-		if err != nil {
-			logrus.Fatalf("Could not find or load Google APIs library: %v", err)
-		} else {
-			logrus.Debugf("Google APIs requested, cache location: %s, will be downloaded: %t", googleAPIsCache, shouldDownload)
+		googleAPIsRevision := GOOGLEAPIS_DEFAULT_REVISION
+		if lock.GoogleAPIs != nil {
+			googleAPIsRevision = lock.GoogleAPIs.Version
 		}
 
-		if shouldDownload {
-			logrus.Debug("Downloading Google APIs library...")
-			googleAPIs, err := downloadGoogleAPIsVersion(googleAPIsCache)
-			if err != nil {
-				logrus.Fatalf("Could not download or extract Google APIs library: %v", err)
-			}
-			googleAPIsPath = *googleAPIs
-			logrus.Debugf("Google APIs library downloaded to: %s", googleAPIsPath)
-		} else {
-			googleAPIsPath = filepath.Join(googleAPIsCache, GOOGLEAPIS_DIR_NAME)
-			logrus.Debugf("Google APIs library found at: %s", googleAPIsPath)
+		googleAPIsPath, _, err = ensureGoogleAPIs(googleAPIsRevision, config.GoogleAPIs.Mirror, *protogoCache, checksums)
+		if err != nil {
+			logrus.Fatalf("Could not ensure Google APIs library: %v", err)
 		}
 	}
 
 	if len(compilerArgs) > 0 {
-		compilerPath := fmt.Sprintf("PATH=%s%c%s", os.Getenv("PATH"), os.PathListSeparator, *goBin)
-		logrus.Debugf("Compiler will be executed with following PATH: %s", compilerPath)
-
 		if includeProtoStandard {
 			protocIncludePath := filepath.Join(filepath.Dir(compilerExecutable), "include")
 			compilerArgs = append([]string{fmt.Sprintf("-I=\"%s\"", protocIncludePath)}, compilerArgs...)
@@ -246,10 +365,16 @@ func main() {
 		if includeProtoGoogleAPIs {
 			compilerArgs = append([]string{fmt.Sprintf("-I=\"%s\"", googleAPIsPath)}, compilerArgs...)
 		}
+		compilerArgs = append(pluginArgs, compilerArgs...)
 
 		logrus.Debugf("Running compiler command: %s %v", compilerExecutable, compilerArgs)
 		compilerCmd := exec.Command(compilerExecutable, compilerArgs...)
-		compilerCmd.Env = append(compilerCmd.Environ(), compilerPath)
+		if goBin != nil {
+			warnUnmanagedPlugins(compilerArgs, *goBin)
+			compilerPath := fmt.Sprintf("PATH=%s%c%s", os.Getenv("PATH"), os.PathListSeparator, *goBin)
+			logrus.Debugf("Compiler will be executed with following PATH: %s", compilerPath)
+			compilerCmd.Env = append(compilerCmd.Environ(), compilerPath)
+		}
 		compilerCmd.Stderr = os.Stderr
 		compilerCmd.Stdout = os.Stdout
 		err = compilerCmd.Run()
@@ -1,51 +1,158 @@
 package main
 
 import (
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
 
 const (
-	GET_HTTP              = "GET"
-	GITHUB_API_VERSION    = "2022-11-28"
-	GITHUB_AGENT_NAME     = "Protogo App"
-	PROTOC_ZIP_NAME       = "protoc-%s-%s.zip"
-	LATEST_PROTOC_RELEASE = "https://api.github.com/repos/protocolbuffers/protobuf/releases/latest"
-	PROTOC_BINARY_URL     = "https://github.com/protocolbuffers/protobuf/releases/download/v%s/%s"
-	FLATC_ZIP_NAME        = "%s.flatc.binary%s.zip"
-	LATEST_FLATC_RELEASE  = "https://api.github.com/repos/google/flatbuffers/releases/latest"
-	FLATC_BINARY_URL      = "https://github.com/google/flatbuffers/releases/download/v%s/%s"
-	GOOGLEAPIS_BINARY_URL = "https://github.com/googleapis/api-common-protos/archive/refs/heads/main.zip"
-	GOOGLEAPIS_DIR_NAME   = "api-common-protos-main"
+	GET_HTTP                    = "GET"
+	GITHUB_API_VERSION          = "2022-11-28"
+	GITHUB_AGENT_NAME           = "Protogo App"
+	PROTOC_ZIP_NAME             = "protoc-%s-%s.zip"
+	LATEST_PROTOC_RELEASE       = "https://api.github.com/repos/protocolbuffers/protobuf/releases/latest"
+	PROTOC_RELEASE_INFO         = "https://api.github.com/repos/protocolbuffers/protobuf/releases/tags/v%s"
+	PROTOC_DOWNLOAD_BASE        = "https://github.com/protocolbuffers/protobuf/releases/download"
+	FLATC_ZIP_NAME              = "%s.flatc.binary%s.zip"
+	LATEST_FLATC_RELEASE        = "https://api.github.com/repos/google/flatbuffers/releases/latest"
+	FLATC_RELEASE_INFO          = "https://api.github.com/repos/google/flatbuffers/releases/tags/v%s"
+	PROTOC_RELEASES_LIST        = "https://api.github.com/repos/protocolbuffers/protobuf/releases?per_page=%d&page=%d"
+	FLATC_RELEASES_LIST         = "https://api.github.com/repos/google/flatbuffers/releases?per_page=%d&page=%d"
+	RELEASES_PAGE_SIZE          = 100
+	MAX_RELEASES_PAGES          = 10
+	FLATC_DOWNLOAD_BASE         = "https://github.com/google/flatbuffers/releases/download"
+	LATEST_GOOGLEAPIS_REV       = "https://api.github.com/repos/googleapis/api-common-protos/commits/main"
+	GOOGLEAPIS_DOWNLOAD_BASE    = "https://github.com/googleapis/api-common-protos/archive"
+	RELEASE_BINARY_URL          = "%s/v%s/%s"
+	ARCHIVE_BINARY_URL          = "%s/%s.zip"
+	MIRROR_INDEX_URL            = "%s/index.json"
+	GOOGLEAPIS_DIR_PREFIX       = "api-common-protos-"
+	GOOGLEAPIS_DIR_NAME         = GOOGLEAPIS_DIR_PREFIX + "%s"
+	GOOGLEAPIS_DEFAULT_REVISION = "main"
+	SHA256_DIGEST_PREFIX        = "sha256:"
+	ZIP_ARCHIVE_SUFFIX          = ".zip"
+	TAR_ARCHIVE_SUFFIX          = ".tar"
+	TAR_GZ_ARCHIVE_SUFFIX       = ".tar.gz"
+	TGZ_ARCHIVE_SUFFIX          = ".tgz"
+	TAR_XZ_ARCHIVE_SUFFIX       = ".tar.xz"
+	TXZ_ARCHIVE_SUFFIX          = ".txz"
+	LATEST_RELEASE              = "https://api.github.com/repos/%s/releases/latest"
+	RELEASE_INFO                = "https://api.github.com/repos/%s/releases/tags/%s"
+	RELEASE_DOWNLOAD_BASE       = "https://github.com/%s/releases/download"
+	RELEASE_TAG_BINARY_URL      = "%s/%s/%s"
+
+	DEFAULT_CONNECT_TIMEOUT   = 30 * time.Second
+	DEFAULT_REQUEST_TIMEOUT   = 10 * time.Minute
+	DEFAULT_REQUEST_RETRIES   = 3
+	RETRY_INITIAL_BACKOFF     = time.Second
+	RETRY_MAX_BACKOFF         = 30 * time.Second
+	MAX_BUFFERED_ASSET_SIZE   = 32 << 20
+	ERROR_BODY_SNIPPET_LENGTH = 256
+	HTTP_KEEP_ALIVE_INTERVAL  = 30 * time.Second
 )
 
+// Offline mode flag, no network requests are allowed if it is set.
+var networkOffline = false
+
+// Number of times a failed request is retried (transient network errors and 5xx responses only).
+var networkRetries = DEFAULT_REQUEST_RETRIES
+
+// Checksums required flag, archives with unknown expected digests are rejected if it is set.
+var checksumsRequired = false
+
+// Maximum time to wait for GitHub API rate limit reset, fail immediately if the reset happens later.
+var networkRateLimitWait time.Duration = 0
+
+// HTTP client, used for all the "protogo" requests.
+var httpClient = makeHTTPClient(DEFAULT_CONNECT_TIMEOUT, DEFAULT_REQUEST_TIMEOUT)
+
+// Create HTTP client with the given timeouts.
+// Proxy settings are taken from the environment, just like for the default client.
+// Redirects are checked with "checkRedirect", so that credentials are never forwarded to other hosts.
+//
+// Accept connect timeout (connection establishment and TLS handshake) and total request timeout (including response reading).
+// Return HTTP client pointer.
+func makeHTTPClient(connectTimeout, timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: connectTimeout, KeepAlive: HTTP_KEEP_ALIVE_INTERVAL}).DialContext
+	transport.TLSHandshakeTimeout = connectTimeout
+	return &http.Client{Transport: transport, Timeout: timeout, CheckRedirect: checkRedirect}
+}
+
+// Configure network access: offline mode, timeouts, retries, rate limit waiting and per-host credentials.
+//
+// Accept offline mode flag and network configuration pointer.
+func configureNetwork(offline bool, config *NetworkConfig) {
+	networkOffline = offline
+	networkRetries = max(config.Retries, 0)
+	networkRateLimitWait = config.RateLimitWait
+	hostCredentials = make(map[string]string, len(config.Credentials))
+	for host, token := range config.Credentials {
+		hostCredentials[strings.ToLower(host)] = token
+	}
+	httpClient = makeHTTPClient(config.ConnectTimeout, config.Timeout)
+}
+
+// Configure archive verification: whether archives with unknown expected digests are rejected.
+//
+// Accept checksums required flag.
+func configureChecksums(required bool) {
+	checksumsRequired = required
+}
+
+// Read the beginning of the response body, for use in error messages.
+//
+// Accept HTTP response pointer.
+// Return body snippet (trimmed, possibly empty).
+func readResponseSnippet(resp *http.Response) string {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, ERROR_BODY_SNIPPET_LENGTH))
+	snippet := strings.TrimSpace(string(data))
+	if len(data) == ERROR_BODY_SNIPPET_LENGTH {
+		snippet += "..."
+	}
+	return snippet
+}
+
 // Make GET HTTP request to GitHub API.
-// Add "Authorization: Bearer ..." header if "PROTOGO_GITHUB_BEARER_TOKEN" environmental variable is found.
+// Add "Authorization: Bearer ..." header if credentials for the URL host are found (see "getHostCredentials").
+// On cross-host redirects the credentials are replaced with the ones for the new host (or removed).
 // Add "User-Agent" header for app authentication and "X-GitHub-Api-Version" for ensuring GitHub API version.
 // Add "Accept" header with either "application/octet-stream" or "application/vnd.github+json" depending on the "binary" argument vaule.
-// Send the request using the default HTTP client.
+// Send the request using the configured HTTP client, retry transient network errors and 5xx (or 429) responses with exponential backoff.
+// If GitHub API rate limit is exceeded, wait until it is reset (if total waiting time fits into the configured rate limit wait) or fail with the reset time.
+// Rate limit waiting does not consume retry attempts.
+// Any other non-2xx response is an error, containing response status and body snippet.
+// Fail immediately in offline mode.
 //
 // Accept URL to make request to and boolean flag, whether binary or JSON response is expected.
-// Return HTTP response pointer and error.
+// Return HTTP response pointer (with 2xx status) and error.
 func makeGETRequestToGitHubAPI(url string, binary bool) (*http.Response, error) {
+	if networkOffline {
+		return nil, fmt.Errorf("offline mode is enabled, request to %s is not allowed", url)
+	}
+
 	req, err := http.NewRequest(GET_HTTP, url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("error creating http GET request to %s: %v", url, err)
 	}
 
-	if value, ok := os.LookupEnv("PROTOGO_GITHUB_BEARER_TOKEN"); ok {
-		logrus.Debug("GitHub API authorization token set!")
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", value))
-	} else {
-		logrus.Debug("GitHub API authorization token not set!")
-	}
-
+	setRequestAuthorization(req)
 	req.Header.Set("X-GitHub-Api-Version", GITHUB_API_VERSION)
 	req.Header.Set("User-Agent", GITHUB_AGENT_NAME)
 
@@ -55,19 +162,542 @@ func makeGETRequestToGitHubAPI(url string, binary bool) (*http.Response, error)
 		req.Header.Set("Accept", "application/vnd.github+json")
 	}
 
-	res, err := http.DefaultClient.Do(req)
+	attempt := 1
+	waited := time.Duration(0)
+	backoff := RETRY_INITIAL_BACKOFF
+	for {
+		res, err := httpClient.Do(req)
+		if err != nil {
+			err = fmt.Errorf("error executing http GET request to %s: %v", url, err)
+		} else if res.StatusCode >= http.StatusOK && res.StatusCode < http.StatusMultipleChoices {
+			logRateLimit(res)
+			return res, nil
+		} else if wait, limited := getRateLimitWait(res); limited {
+			res.Body.Close()
+			err = makeRateLimitError(url, res, wait)
+			wait = max(wait, RETRY_INITIAL_BACKOFF)
+			if waited+wait > networkRateLimitWait {
+				return nil, err
+			}
+
+			logrus.Warnf("Request failed: %v, waiting %v for the rate limit reset...", err, wait)
+			time.Sleep(wait)
+			waited += wait
+			continue
+		} else {
+			err = fmt.Errorf("http GET request to %s failed with status '%s': %s", url, res.Status, readResponseSnippet(res))
+			res.Body.Close()
+			if res.StatusCode < http.StatusInternalServerError && res.StatusCode != http.StatusTooManyRequests {
+				return nil, err
+			}
+		}
+
+		if attempt > networkRetries {
+			return nil, err
+		}
+
+		logrus.Warnf("Request failed: %v, retrying in %v (attempt %d of %d)...", err, backoff, attempt, networkRetries)
+		time.Sleep(backoff)
+		backoff = min(backoff*2, RETRY_MAX_BACKOFF)
+		attempt++
+	}
+}
+
+// Release asset, as described in GitHub release metadata.
+type releaseAsset struct {
+	Name   string `json:"name"`
+	Digest string `json:"digest"`
+}
+
+// Release assets, already requested from GitHub API, by release info URL.
+var releaseAssetsCache = make(map[string][]releaseAsset)
+
+// Get release assets list from GitHub release metadata, making GitHub API request.
+// Every release is requested only once per run, the assets are reused for both asset discovery and digest verification.
+//
+// Accept release info URL.
+// Return release assets list and error.
+func getReleaseAssets(releaseURL string) ([]releaseAsset, error) {
+	if assets, ok := releaseAssetsCache[releaseURL]; ok {
+		logrus.Debugf("Using already requested release info: %s", releaseURL)
+		return assets, nil
+	}
+
+	logrus.Debugf("Downloading release info: %s", releaseURL)
+	resp, err := makeGETRequestToGitHubAPI(releaseURL, false)
+	if err != nil {
+		return nil, fmt.Errorf("reading release info error: %v", err)
+	} else {
+		defer resp.Body.Close()
+	}
+
+	logrus.Debug("Decoding release info JSON...")
+	var responseJSON struct {
+		Assets []releaseAsset `json:"assets"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&responseJSON)
+	if err != nil {
+		return nil, fmt.Errorf("release info parsing error: %v", err)
+	}
+
+	releaseAssetsCache[releaseURL] = responseJSON.Assets
+	return responseJSON.Assets, nil
+}
+
+// Compare asset names naturally: digit sequences are compared as numbers (e.g. "g++-9" < "g++-13"), the other characters are compared as is.
+//
+// Accept two asset names.
+// Return -1, 0 or 1 if the first asset name is less, equal or greater than the second one.
+func compareAssetNames(a, b string) int {
+	for a != "" && b != "" {
+		aNumber, aSuffix := splitVersionComponent(a)
+		bNumber, bSuffix := splitVersionComponent(b)
+		if aNumber != bNumber {
+			return cmp.Compare(aNumber, bNumber)
+		} else if aNumber != -1 {
+			a, b = aSuffix, bSuffix
+		} else if a[0] != b[0] {
+			return cmp.Compare(a[0], b[0])
+		} else {
+			a, b = a[1:], b[1:]
+		}
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
+// Find the release asset, best matching the current platform.
+// Patterns are checked in the order of preference, the first pattern, matching any of the assets, is used.
+// If the pattern matches several assets, the one with the highest version (e.g. "g++-13" rather than "g++-10") is chosen.
+//
+// Accept release info URL and asset name patterns (in [path.Match] format), ranked by preference.
+// Return asset name and error (listing all the release assets if none match).
+func findReleaseAsset(releaseURL string, patterns []string) (string, error) {
+	assets, err := getReleaseAssets(releaseURL)
+	if err != nil {
+		return "", err
+	}
+
+	var names []string
+	for _, asset := range assets {
+		names = append(names, asset.Name)
+	}
+
+	for rank, pattern := range patterns {
+		var matches []string
+		for _, name := range names {
+			if matched, _ := path.Match(pattern, name); matched {
+				matches = append(matches, name)
+			}
+		}
+
+		if len(matches) == 0 {
+			logrus.Debugf("No release assets match pattern: %s", pattern)
+			continue
+		}
+
+		best := slices.MaxFunc(matches, compareAssetNames)
+		if rank > 0 {
+			logrus.Infof("No release assets match preferred pattern %s, using compatible asset: %s", patterns[0], best)
+		} else {
+			logrus.Debugf("Release asset matching pattern %s found: %s", pattern, best)
+		}
+		return best, nil
+	}
+
+	return "", fmt.Errorf("no release assets match the current platform (patterns: %s), available assets: %s", strings.Join(patterns, ", "), strings.Join(names, ", "))
+}
+
+// Get release asset SHA-256 digest from GitHub release metadata.
+// Find the asset by name and extract "digest" value from it.
+//
+// Accept release info URL and asset name.
+// Return hex-encoded asset SHA-256 digest pointer and error.
+func getReleaseAssetDigest(releaseURL, asset string) (*string, error) {
+	assets, err := getReleaseAssets(releaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.Debugf("Searching for asset %s digest...", asset)
+	for _, releaseAsset := range assets {
+		if releaseAsset.Name != asset {
+			continue
+		} else if !strings.HasPrefix(releaseAsset.Digest, SHA256_DIGEST_PREFIX) {
+			return nil, fmt.Errorf("release asset %s has no SHA-256 digest, but: '%s'", asset, releaseAsset.Digest)
+		}
+
+		digest := normalizeDigest(releaseAsset.Digest)
+		return &digest, nil
+	}
+
+	return nil, fmt.Errorf("release asset %s not found", asset)
+}
+
+// Normalize SHA-256 digest: remove optional "sha256:" prefix and convert to lower case.
+//
+// Accept digest string.
+// Return hex-encoded digest.
+func normalizeDigest(digest string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(digest), SHA256_DIGEST_PREFIX))
+}
+
+// Find expected SHA-256 digest of an archive.
+// Known checksums (from lock file and configuration) are checked first, then GitHub release metadata is requested (if release URL is given).
+// If the digest is unknown, the archive is either installed without verification (with a warning) or rejected (if checksums are required).
+//
+// Accept known checksums (asset name to digest map), asset name and release info URL (or empty string if there is no release).
+// Return hex-encoded expected digest (or empty string if it is unknown) and error.
+func getExpectedDigest(checksums map[string]string, asset, releaseURL string) (string, error) {
+	if digest, ok := checksums[asset]; ok {
+		logrus.Debugf("Expected digest for %s is known: %s", asset, digest)
+		return normalizeDigest(digest), nil
+	}
+
+	if releaseURL != "" {
+		digest, err := getReleaseAssetDigest(releaseURL, asset)
+		if err == nil {
+			logrus.Debugf("Expected digest for %s found in release info: %s", asset, *digest)
+			return *digest, nil
+		} else {
+			logrus.Debugf("Expected digest for %s not found in release info: %v", asset, err)
+		}
+	}
+
+	if checksumsRequired {
+		return "", fmt.Errorf("expected digest for %s is unknown and checksums are required (add it to 'checksums' config section or run 'protogo lock')", asset)
+	}
+
+	logrus.Warnf("Expected digest for %s is unknown, archive will not be verified!", asset)
+	return "", nil
+}
+
+// Download release asset contents, calculating its SHA-256 digest while downloading.
+// Small assets (with known size) are buffered in memory, larger ones are saved to a uniquely named temporary file,
+// which is removed by the returned cleanup function (or on interruption).
+//
+// Accept HTTP response pointer and asset name.
+// Return asset contents, asset size, hex-encoded asset SHA-256 digest, cleanup function and error.
+func readAsset(resp *http.Response, assetName string) (io.ReaderAt, int64, string, func(), error) {
+	hash := sha256.New()
+
+	if resp.ContentLength >= 0 && resp.ContentLength <= MAX_BUFFERED_ASSET_SIZE {
+		logrus.Debugf("Buffering asset %s (%d bytes) in memory", assetName, resp.ContentLength)
+		buffer := bytes.NewBuffer(make([]byte, 0, resp.ContentLength))
+		n, err := io.Copy(io.MultiWriter(buffer, hash), resp.Body)
+		if err != nil {
+			return nil, 0, "", nil, fmt.Errorf("response copying error: %v", err)
+		}
+		return bytes.NewReader(buffer.Bytes()), n, hex.EncodeToString(hash.Sum(nil)), func() {}, nil
+	}
+
+	logrus.Debugf("Creating temporary file for asset: %s", assetName)
+	out, err := os.CreateTemp("", "protogo-*-"+assetName)
+	if err != nil {
+		return nil, 0, "", nil, fmt.Errorf("creating temporary file for '%s' error: %v", assetName, err)
+	}
+
+	removeTemp := registerCleanup(out.Name())
+	cleanup := func() {
+		out.Close()
+		removeTemp()
+	}
+
+	logrus.Debugf("Populating temporary file: %s", out.Name())
+	n, err := io.Copy(io.MultiWriter(out, hash), resp.Body)
+	if err != nil {
+		cleanup()
+		return nil, 0, "", nil, fmt.Errorf("response copying error: %v", err)
+	}
+	return out, n, hex.EncodeToString(hash.Sum(nil)), cleanup, nil
+}
+
+// Download archive from the given URL and unpack it to the specified directory.
+// If the asset is not an archive (ZIP, TAR, TAR.GZ or TAR.XZ), it is considered to be a single executable and is saved to the directory as is.
+// Archive format is detected by the downloaded contents, extraction starts as soon as the download completes.
+// Calculate archive SHA-256 digest while downloading and compare it to the expected one (if known).
+// If digest doesn't match, the archive is not unpacked.
+//
+// Accept archive URL, archive file name, destination directory and hex-encoded expected digest (or empty string if unknown).
+// Return hex-encoded archive SHA-256 digest and error.
+func downloadArchive(url, archiveName, destDir, expectedDigest string) (string, error) {
+	logrus.Debugf("Downloading archive: %s", url)
+	resp, err := makeGETRequestToGitHubAPI(url, true)
+	if err != nil {
+		return "", fmt.Errorf("accessing URL '%s' error: %v", url, err)
+	} else {
+		defer resp.Body.Close()
+	}
+
+	archive, size, digest, cleanup, err := readAsset(resp, archiveName)
+	if err != nil {
+		return "", err
+	} else {
+		defer cleanup()
+		logrus.Debugf("Downloaded file '%s' %d bytes successfully!", archiveName, size)
+	}
+
+	if expectedDigest != "" && digest != expectedDigest {
+		return "", fmt.Errorf("archive %s SHA-256 digest mismatch: expected %s, got %s", archiveName, expectedDigest, digest)
+	} else {
+		logrus.Debugf("Archive %s SHA-256 digest: %s", archiveName, digest)
+	}
+
+	if !isArchiveAsset(archiveName) {
+		logrus.Debugf("Asset %s is not an archive, saving it as executable", archiveName)
+		err = writeExecutable(io.NewSectionReader(archive, 0, size), filepath.Join(destDir, archiveName))
+		if err != nil {
+			return "", fmt.Errorf("asset saving error: %v", err)
+		}
+		return digest, nil
+	}
+
+	logrus.Debugf("Extracting archive: %s", archiveName)
+	err = extractArchive(archive, size, archiveName, destDir)
+	if err != nil {
+		return "", fmt.Errorf("archive extraction error: %v", err)
+	} else {
+		logrus.Debugf("Archive extracted successfully to: %s", destDir)
+	}
+
+	return digest, nil
+}
+
+// Download archive and install it to the cache entry directory atomically.
+// Remove stale staging directories, left by interrupted installations of the same entry.
+// Extract archive into a temporary staging directory next to the entry, verify that the executable and all the required files are present,
+// make the executable executable (ZIP archives may store no permission bits), write archive info and installation completion marker files and rename the staging directory into the entry directory.
+// Entry directory is expected to be locked by the caller.
+//
+// Accept archive URL, archive info (version and asset name), hex-encoded expected digest (or empty string if unknown),
+// cache entry directory, archive root directory (relative path of the entry contents inside the archive, empty string for archive root),
+// executable file path (empty string if none) and other required file paths (relative to the entry directory).
+// Return installed archive info pointer and error.
+func installArchive(url string, info ArchiveInfo, expectedDigest, entryDir, archiveRoot, executable string, required ...string) (*ArchiveInfo, error) {
+	parentDir, entryName := filepath.Split(entryDir)
+	stagingPattern := "." + entryName + CACHE_STAGING_INFIX
+
+	stale, _ := filepath.Glob(filepath.Join(parentDir, stagingPattern+"*"))
+	for _, staging := range stale {
+		logrus.Debugf("Removing stale staging directory: %s", staging)
+		err := os.RemoveAll(staging)
+		if err != nil {
+			return nil, fmt.Errorf("error removing stale staging directory %s: %v", staging, err)
+		}
+	}
+
+	err := os.MkdirAll(parentDir, os.ModePerm)
+	if err != nil {
+		return nil, fmt.Errorf("error making cache directory %s: %v", parentDir, err)
+	}
+
+	stagingDir, err := os.MkdirTemp(parentDir, stagingPattern+"*")
+	if err != nil {
+		return nil, fmt.Errorf("error making staging directory in %s: %v", parentDir, err)
+	} else {
+		logrus.Debugf("Staging directory created: %s", stagingDir)
+		defer registerCleanup(stagingDir)()
+	}
+
+	info.SHA256, err = downloadArchive(url, info.Asset, stagingDir, expectedDigest)
+	if err != nil {
+		return nil, err
+	}
+
+	contentDir := filepath.Join(stagingDir, archiveRoot)
+	for _, file := range append(required, executable) {
+		if _, err := os.Stat(filepath.Join(contentDir, file)); file != "" && err != nil {
+			return nil, fmt.Errorf("archive %s is missing required file %s", info.Asset, file)
+		}
+	}
+
+	if executable != "" {
+		err = makeExecutable(filepath.Join(contentDir, executable))
+		if err != nil {
+			return nil, fmt.Errorf("archive %s executable error: %v", info.Asset, err)
+		}
+	}
+
+	err = writeArchiveInfo(contentDir, &info)
+	if err != nil {
+		return nil, fmt.Errorf("archive info writing error: %v", err)
+	}
+
+	err = commitCacheEntry(contentDir, entryDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// Choose download base URL: either mirror (if configured) or the default one.
+//
+// Accept mirror base URL (or empty string if none) and default base URL.
+// Return base URL without trailing slash.
+func getDownloadBase(mirror, defaultBase string) string {
+	if mirror != "" {
+		return strings.TrimSuffix(mirror, "/")
+	} else {
+		return defaultBase
+	}
+}
+
+// Read mirror index file.
+// Index file is a JSON file named "index.json", located in the mirror root.
+//
+// Accept mirror base URL.
+// Return decoded index file contents and error.
+func readMirrorIndex(mirror string) (map[string]any, error) {
+	indexUrl := fmt.Sprintf(MIRROR_INDEX_URL, getDownloadBase(mirror, ""))
+
+	logrus.Debugf("Downloading mirror index: %s", indexUrl)
+	resp, err := makeGETRequestToGitHubAPI(indexUrl, false)
+	if err != nil {
+		return nil, fmt.Errorf("reading mirror index error: %v", err)
+	} else {
+		defer resp.Body.Close()
+	}
+
+	logrus.Debug("Decoding mirror index JSON...")
+	var responseJSON map[string]any
+	err = json.NewDecoder(resp.Body).Decode(&responseJSON)
+	if err != nil {
+		return nil, fmt.Errorf("mirror index parsing error: %v", err)
+	}
+
+	return responseJSON, nil
+}
+
+// Get latest version available on mirror, reading mirror index file.
+// Index file should contain "latest" field.
+//
+// Accept mirror base URL.
+// Return latest version string pointer and error.
+func getLatestMirrorVersion(mirror string) (*string, error) {
+	responseJSON, err := readMirrorIndex(mirror)
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.Debug("Decoding mirror latest version...")
+	latest, ok := responseJSON["latest"]
+	if !ok {
+		return nil, fmt.Errorf("mirror index 'latest' not found in: %s", responseJSON)
+	}
+
+	logrus.Debug("Extracting version string...")
+	if version, ok := latest.(string); ok {
+		return &version, nil
+	} else {
+		return nil, fmt.Errorf("mirror index 'latest' field is not string, but: %v", latest)
+	}
+}
+
+// Get all the versions available on mirror, reading mirror index file.
+// Index file may contain "versions" field (list of version strings), if it doesn't, only the "latest" version is available.
+//
+// Accept mirror base URL.
+// Return versions list and error.
+func getMirrorVersions(mirror string) ([]string, error) {
+	responseJSON, err := readMirrorIndex(mirror)
 	if err != nil {
-		return nil, fmt.Errorf("error executing http GET request to %s: %v", url, err)
+		return nil, err
+	}
+
+	logrus.Debug("Decoding mirror versions...")
+	var versions []string
+	if list, ok := responseJSON["versions"].([]any); ok {
+		for _, item := range list {
+			if version, ok := item.(string); ok {
+				versions = append(versions, version)
+			} else {
+				return nil, fmt.Errorf("mirror index 'versions' item is not string, but: %v", item)
+			}
+		}
+	} else if latest, ok := responseJSON["latest"].(string); ok {
+		versions = append(versions, latest)
+	} else {
+		return nil, fmt.Errorf("mirror index neither 'versions' nor 'latest' found in: %s", responseJSON)
+	}
+
+	return versions, nil
+}
+
+// List GitHub release tags, making paginated GitHub API requests.
+// Draft releases are skipped, at most "MAX_RELEASES_PAGES" pages are read.
+//
+// Accept releases list URL template (with page size and page number placeholders).
+// Return release tags list (newest releases first) and error.
+func listGitHubReleaseTags(releasesList string) ([]string, error) {
+	var tags []string
+	for page := 1; page <= MAX_RELEASES_PAGES; page++ {
+		releasesURL := fmt.Sprintf(releasesList, RELEASES_PAGE_SIZE, page)
+
+		logrus.Debugf("Downloading releases list page: %s", releasesURL)
+		resp, err := makeGETRequestToGitHubAPI(releasesURL, false)
+		if err != nil {
+			return nil, fmt.Errorf("reading releases list error: %v", err)
+		}
+
+		var releases []struct {
+			TagName string `json:"tag_name"`
+			Draft   bool   `json:"draft"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&releases)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("releases list parsing error: %v", err)
+		}
+
+		for _, release := range releases {
+			if !release.Draft {
+				tags = append(tags, release.TagName)
+			}
+		}
+
+		if len(releases) < RELEASES_PAGE_SIZE {
+			break
+		}
 	}
+	return tags, nil
+}
 
-	return res, nil
+// List all the protoc release versions, available either on GitHub or on mirror.
+//
+// Accept mirror base URL (or empty string if none).
+// Return versions list and error.
+func listProtocVersions(mirror string) ([]string, error) {
+	if mirror != "" {
+		return getMirrorVersions(mirror)
+	} else {
+		return listGitHubReleaseTags(PROTOC_RELEASES_LIST)
+	}
+}
+
+// List all the flatc release versions, available either on GitHub or on mirror.
+//
+// Accept mirror base URL (or empty string if none).
+// Return versions list and error.
+func listFlatcVersions(mirror string) ([]string, error) {
+	if mirror != "" {
+		return getMirrorVersions(mirror)
+	} else {
+		return listGitHubReleaseTags(FLATC_RELEASES_LIST)
+	}
 }
 
 // Get latest protoc release tag, making GitHub API request.
 // Decode JSON response and extract "tag_name" value from it.
 //
+// If mirror is configured, read latest tag from mirror index instead.
+//
+// Accept mirror base URL (or empty string if none).
 // Return latest tag string pointer and error.
-func getLatestProtocReleaseTag() (*string, error) {
+func getLatestProtocReleaseTag(mirror string) (*string, error) {
+	if mirror != "" {
+		return getLatestMirrorVersion(mirror)
+	}
+
 	logrus.Debugf("Downloading latest protoc release info: %s", LATEST_PROTOC_RELEASE)
 	resp, err := makeGETRequestToGitHubAPI(LATEST_PROTOC_RELEASE, false)
 	if err != nil {
@@ -98,66 +728,69 @@ func getLatestProtocReleaseTag() (*string, error) {
 }
 
 // Download protoc compiler from GitHub releases, unpack it and save to the specified cache directory.
-// Use current package GOOS and GOARCH values for exact binary location.
-// Save downloaded archive to a temporary directory, remove it after unpacking.
+// Use current package GOOS and GOARCH values for binary discovery: the release assets list is requested and the best matching asset is chosen.
+// Install the archive atomically, so that interrupted downloads never leave incomplete compiler in cache.
+// If mirror is configured, download the asset with the default name from mirror instead (GitHub release metadata is not used for discovery and verification then).
 //
-// Accept protobuf compiler version (without "v" prefix) and cache directory to store compiler binaries.
-// Return compiler executable path pointer and error.
-func downloadProtocVersion(version, cacheDir string) (*string, error) {
-	platform, err := getProtocOSandArch()
-	if err != nil {
-		return nil, fmt.Errorf("error parsing current OS and architecture: %v", err)
-	} else {
-		logrus.Debugf("Current protoc architecture: %s", *platform)
-	}
+// Accept protobuf compiler version (without "v" prefix), mirror base URL (or empty string if none), cache directory to store compiler binaries and known archive checksums.
+// Return compiler executable path pointer, downloaded archive info pointer and error.
+func downloadProtocVersion(version, mirror, cacheDir string, checksums map[string]string) (*string, *ArchiveInfo, error) {
+	var protocZip, protocReleaseInfo string
+	if mirror == "" {
+		patterns, err := getProtocAssetPatterns()
+		if err != nil {
+			return nil, nil, fmt.Errorf("error parsing current OS and architecture: %v", err)
+		} else {
+			logrus.Debugf("Current protoc asset patterns: %v", patterns)
+		}
 
-	protocZip := fmt.Sprintf(PROTOC_ZIP_NAME, version, *platform)
-	protocDownloadUrl := fmt.Sprintf(PROTOC_BINARY_URL, version, protocZip)
-
-	logrus.Debugf("Downloading protoc release: %s", protocDownloadUrl)
-	resp, err := makeGETRequestToGitHubAPI(protocDownloadUrl, true)
-	if err != nil {
-		return nil, fmt.Errorf("accessing URL '%s' error: %v", protocDownloadUrl, err)
+		protocReleaseInfo = fmt.Sprintf(PROTOC_RELEASE_INFO, version)
+		protocZip, err = findReleaseAsset(protocReleaseInfo, patterns)
+		if err != nil {
+			return nil, nil, fmt.Errorf("protoc %s release asset couldn't be found: %v", version, err)
+		}
 	} else {
-		defer resp.Body.Close()
+		platform, err := getProtocOSandArch(runtime.GOOS, runtime.GOARCH)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error parsing current OS and architecture: %v", err)
+		} else {
+			logrus.Debugf("Current protoc architecture: %s", *platform)
+		}
+		protocZip = fmt.Sprintf(PROTOC_ZIP_NAME, version, *platform)
 	}
 
-	protocArchive := filepath.Join(os.TempDir(), protocZip)
-
-	logrus.Debugf("Creating protoc archive: %s", protocArchive)
-	out, err := os.Create(protocArchive)
-	if err != nil {
-		return nil, fmt.Errorf("creating file '%s' error: %v", protocArchive, err)
-	} else {
-		defer out.Close()
-		defer os.Remove(protocArchive)
-	}
+	protocDownloadUrl := fmt.Sprintf(RELEASE_BINARY_URL, getDownloadBase(mirror, PROTOC_DOWNLOAD_BASE), version, protocZip)
+	logrus.Debugf("Downloading protoc release: %s", protocDownloadUrl)
 
-	logrus.Debugf("Populating protoc archive: %s", protocArchive)
-	n, err := io.Copy(out, resp.Body)
+	expectedDigest, err := getExpectedDigest(checksums, protocZip, protocReleaseInfo)
 	if err != nil {
-		return nil, fmt.Errorf("response copying error: %v", err)
-	} else {
-		logrus.Debugf("Downloaded file '%s' %d bytes successfully!", protocZip, n)
+		return nil, nil, fmt.Errorf("protoc archive verification error: %v", err)
 	}
 
-	logrus.Debugf("Unzipping protoc archive: %s", protocArchive)
-	err = unzip(protocArchive, cacheDir)
+	protocExecName := filepath.Join("bin", getExecutableName(PROTOC_EXECUTABLE))
+	info, err := installArchive(protocDownloadUrl, ArchiveInfo{Version: version, Asset: protocZip}, expectedDigest, cacheDir, "", protocExecName, "include")
 	if err != nil {
-		return nil, fmt.Errorf("protoc archive unzipping error: %v", err)
+		return nil, nil, fmt.Errorf("protoc archive installation error: %v", err)
 	} else {
-		logrus.Debugf("Protoc archive extracted successfully to: %s", cacheDir)
+		logrus.Debugf("Protoc archive installed successfully to: %s", cacheDir)
 	}
 
-	protocExec := filepath.Join(cacheDir, "bin", getExecutableName(PROTOC_EXECUTABLE))
-	return &protocExec, nil
+	protocExec := filepath.Join(cacheDir, protocExecName)
+	return &protocExec, info, nil
 }
 
 // Get latest flatc release tag, making GitHub API request.
 // Decode JSON response and extract "tag_name" value from it.
 //
+// If mirror is configured, read latest tag from mirror index instead.
+//
+// Accept mirror base URL (or empty string if none).
 // Return latest tag string pointer and error.
-func getLatestFlatcReleaseTag() (*string, error) {
+func getLatestFlatcReleaseTag(mirror string) (*string, error) {
+	if mirror != "" {
+		return getLatestMirrorVersion(mirror)
+	}
+
 	logrus.Debugf("Downloading latest flatc release info: %s", LATEST_FLATC_RELEASE)
 	resp, err := makeGETRequestToGitHubAPI(LATEST_FLATC_RELEASE, false)
 	if err != nil {
@@ -188,104 +821,365 @@ func getLatestFlatcReleaseTag() (*string, error) {
 }
 
 // Download flatc compiler from GitHub releases, unpack it and save to the specified cache directory.
-// Use current package GOOS and GOARCH values for exact binary location.
-// Save downloaded archive to a temporary directory, remove it after unpacking.
+// Use current package GOOS and GOARCH values for binary discovery: the release assets list is requested and the best matching asset is chosen.
+// Install the archive atomically, so that interrupted downloads never leave incomplete compiler in cache.
+// If mirror is configured, download the asset with the default name from mirror instead (GitHub release metadata is not used for discovery and verification then).
 //
-// Accept flatbuffers compiler version (without "v" prefix) and cache directory to store compiler binaries.
-// Return compiler executable path pointer and error.
-func downloadFlatcVersion(version, cacheDir string) (*string, error) {
-	system, addition, err := getFlatcOSandAddition()
-	if err != nil {
-		return nil, fmt.Errorf("error parsing current OS and architecture: %v", err)
+// Accept flatbuffers compiler version (without "v" prefix), linux distribution of flatc, mirror base URL (or empty string if none), cache directory to store compiler binaries and known archive checksums.
+// Return compiler executable path pointer, downloaded archive info pointer and error.
+func downloadFlatcVersion(version, distro, mirror, cacheDir string, checksums map[string]string) (*string, *ArchiveInfo, error) {
+	var flatcZip, flatcReleaseInfo string
+	if mirror == "" {
+		patterns, err := getFlatcAssetPatterns(distro)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error parsing current OS and architecture: %v", err)
+		} else {
+			logrus.Debugf("Current flatc asset patterns: %v", patterns)
+		}
+
+		flatcReleaseInfo = fmt.Sprintf(FLATC_RELEASE_INFO, version)
+		flatcZip, err = findReleaseAsset(flatcReleaseInfo, patterns)
+		if err != nil {
+			return nil, nil, fmt.Errorf("flatc %s release asset couldn't be found: %v", version, err)
+		}
 	} else {
-		logrus.Debugf("Current flatc architecture: %s (%s)", *system, addition)
+		system, addition, err := getFlatcOSandAddition(runtime.GOOS, runtime.GOARCH, distro)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error parsing current OS and architecture: %v", err)
+		} else {
+			logrus.Debugf("Current flatc architecture: %s (%s)", *system, addition)
+		}
+		flatcZip = fmt.Sprintf(FLATC_ZIP_NAME, *system, addition)
 	}
 
-	flatcZip := fmt.Sprintf(FLATC_ZIP_NAME, *system, addition)
-	flatcDownloadUrl := fmt.Sprintf(FLATC_BINARY_URL, version, flatcZip)
-
+	flatcDownloadUrl := fmt.Sprintf(RELEASE_BINARY_URL, getDownloadBase(mirror, FLATC_DOWNLOAD_BASE), version, flatcZip)
 	logrus.Debugf("Downloading flatc release: %s", flatcDownloadUrl)
-	resp, err := makeGETRequestToGitHubAPI(flatcDownloadUrl, true)
+
+	expectedDigest, err := getExpectedDigest(checksums, flatcZip, flatcReleaseInfo)
 	if err != nil {
-		return nil, fmt.Errorf("accessing URL '%s' error: %v", flatcDownloadUrl, err)
+		return nil, nil, fmt.Errorf("flatc archive verification error: %v", err)
+	}
+
+	flatcExecName := getExecutableName(FLATC_EXECUTABLE)
+	info, err := installArchive(flatcDownloadUrl, ArchiveInfo{Version: version, Asset: flatcZip}, expectedDigest, cacheDir, "", flatcExecName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("flatc archive installation error: %v", err)
 	} else {
-		defer resp.Body.Close()
+		logrus.Debugf("Flatc archive installed successfully to: %s", cacheDir)
 	}
 
-	flatcArchive := filepath.Join(os.TempDir(), flatcZip)
+	flatcExec := filepath.Join(cacheDir, flatcExecName)
+	return &flatcExec, info, nil
+}
 
-	logrus.Debugf("Creating flatc archive: %s", flatcArchive)
-	out, err := os.Create(flatcArchive)
+// Get latest Google APIs library revision (main branch head commit), making GitHub API request.
+// Decode JSON response and extract "sha" value from it.
+//
+// If mirror is configured, read latest revision from mirror index instead.
+//
+// Accept mirror base URL (or empty string if none).
+// Return latest revision string pointer and error.
+func getLatestGoogleAPIsRevision(mirror string) (*string, error) {
+	if mirror != "" {
+		return getLatestMirrorVersion(mirror)
+	}
+
+	logrus.Debugf("Downloading latest Google APIs library commit info: %s", LATEST_GOOGLEAPIS_REV)
+	resp, err := makeGETRequestToGitHubAPI(LATEST_GOOGLEAPIS_REV, false)
 	if err != nil {
-		return nil, fmt.Errorf("creating file '%s' error: %v", flatcArchive, err)
+		return nil, fmt.Errorf("reading latest Google APIs library commit error: %v", err)
 	} else {
-		defer out.Close()
-		defer os.Remove(flatcArchive)
+		defer resp.Body.Close()
 	}
 
-	logrus.Debugf("Populating flatc archive: %s", flatcArchive)
-	n, err := io.Copy(out, resp.Body)
+	logrus.Debug("Decoding latest Google APIs library commit JSON...")
+	var responseJSON map[string]any
+	err = json.NewDecoder(resp.Body).Decode(&responseJSON)
 	if err != nil {
-		return nil, fmt.Errorf("response copying error: %v", err)
+		return nil, fmt.Errorf("latest Google APIs library commit info parsing error: %v", err)
+	}
+
+	logrus.Debug("Decoding latest Google APIs library revision...")
+	sha, ok := responseJSON["sha"]
+	if !ok {
+		return nil, fmt.Errorf("latest Google APIs library commit info 'sha' not found in: %s", responseJSON)
+	}
+
+	logrus.Debug("Extracting revision string...")
+	if revision, ok := sha.(string); ok {
+		return &revision, nil
 	} else {
-		logrus.Debugf("Downloaded file '%s' %d bytes successfully!", flatcZip, n)
+		return nil, fmt.Errorf("latest Google APIs library commit info 'sha' field is not string, but: %v", sha)
+	}
+}
+
+// Download Google APIs library of the given revision from GitHub, unpack it and save to the specified cache directory.
+// Install the archive atomically, so that interrupted downloads never leave incomplete library in cache.
+// If mirror is configured, download from mirror instead.
+//
+// Accept Google APIs library revision (branch name or commit hash), mirror base URL (or empty string if none), cache directory to store library files and known archive checksums.
+// Return Google APIs library path pointer, downloaded archive info pointer and error.
+func downloadGoogleAPIsVersion(revision, mirror, cacheDir string, checksums map[string]string) (*string, *ArchiveInfo, error) {
+	googleAPIsDirName := fmt.Sprintf(GOOGLEAPIS_DIR_NAME, revision)
+	googleAPIsArchiveName := fmt.Sprintf("%s.zip", googleAPIsDirName)
+	googleAPIsDownloadUrl := fmt.Sprintf(ARCHIVE_BINARY_URL, getDownloadBase(mirror, GOOGLEAPIS_DOWNLOAD_BASE), revision)
+
+	logrus.Debugf("Downloading Google APIs library release: %s", googleAPIsDownloadUrl)
+	expectedDigest, err := getExpectedDigest(checksums, googleAPIsArchiveName, "")
+	if err != nil {
+		return nil, nil, fmt.Errorf("Google APIs library archive verification error: %v", err)
 	}
 
-	logrus.Debugf("Unzipping flatc archive: %s", flatcArchive)
-	err = unzip(flatcArchive, cacheDir)
+	googleAPIsDir := filepath.Join(cacheDir, googleAPIsDirName)
+	info, err := installArchive(googleAPIsDownloadUrl, ArchiveInfo{Version: revision, Asset: googleAPIsArchiveName}, expectedDigest, googleAPIsDir, googleAPIsDirName, "", "google")
 	if err != nil {
-		return nil, fmt.Errorf("flatc archive unzipping error: %v", err)
+		return nil, nil, fmt.Errorf("Google APIs library archive installation error: %v", err)
 	} else {
-		logrus.Debugf("Flatc archive extracted successfully to: %s", cacheDir)
+		logrus.Debugf("Google APIs library archive installed successfully to: %s", googleAPIsDir)
 	}
 
-	flatcExec := filepath.Join(cacheDir, getExecutableName(FLATC_EXECUTABLE))
-	return &flatcExec, nil
+	return &googleAPIsDir, info, nil
 }
 
-// Download Google APIs library from GitHub main branch, unpack it and save to the specified cache directory.
-// Save downloaded archive to a temporary directory, remove it after unpacking.
+// Get latest prebuilt plugin version, making GitHub API request for the latest release of the plugin repository.
+// Decode JSON response, extract "tag_name" value from it and convert it to version using plugin tag template.
+//
+// If mirror is configured, read latest version from mirror index instead.
 //
-// Accept cache directory to store compiler binaries.
-// Return Google APIs library path pointer and error.
-func downloadGoogleAPIsVersion(cacheDir string) (*string, error) {
-	googleAPIsArchiveName := fmt.Sprintf("%s.zip", GOOGLEAPIS_DIR_NAME)
+// Accept prebuilt plugin configuration.
+// Return latest version string pointer and error.
+func getLatestBinaryPluginVersion(plugin BinaryPluginConfig) (*string, error) {
+	if plugin.Mirror != "" {
+		return getLatestMirrorVersion(plugin.Mirror)
+	}
 
-	logrus.Debugf("Downloading Google APIs library release: %s", GOOGLEAPIS_BINARY_URL)
-	resp, err := makeGETRequestToGitHubAPI(GOOGLEAPIS_BINARY_URL, true)
+	latestRelease := fmt.Sprintf(LATEST_RELEASE, plugin.Repository)
+	logrus.Debugf("Downloading latest %s release info: %s", plugin.Name, latestRelease)
+	resp, err := makeGETRequestToGitHubAPI(latestRelease, false)
 	if err != nil {
-		return nil, fmt.Errorf("accessing URL '%s' error: %v", GOOGLEAPIS_BINARY_URL, err)
+		return nil, fmt.Errorf("reading latest %s release error: %v", plugin.Repository, err)
 	} else {
 		defer resp.Body.Close()
 	}
 
-	googleAPIsArchive := filepath.Join(os.TempDir(), googleAPIsArchiveName)
+	logrus.Debugf("Decoding latest %s release JSON...", plugin.Name)
+	var responseJSON map[string]any
+	err = json.NewDecoder(resp.Body).Decode(&responseJSON)
+	if err != nil {
+		return nil, fmt.Errorf("latest %s release info parsing error: %v", plugin.Repository, err)
+	}
+
+	logrus.Debugf("Decoding latest %s release version...", plugin.Name)
+	tag, ok := responseJSON["tag_name"]
+	if !ok {
+		return nil, fmt.Errorf("latest %s release info 'tag_name' not found in: %s", plugin.Repository, responseJSON)
+	}
 
-	logrus.Debugf("Creating Google APIs library archive: %s", googleAPIsArchive)
-	out, err := os.Create(googleAPIsArchive)
+	logrus.Debug("Extracting version string...")
+	if tagName, ok := tag.(string); ok {
+		version := getBinaryPluginVersionFromTag(plugin, tagName)
+		return &version, nil
+	} else {
+		return nil, fmt.Errorf("latest %s release info 'tag_name' field is not string, but: %v", plugin.Repository, tag)
+	}
+}
+
+// Download prebuilt plugin from GitHub releases, unpack it (if it is an archive) and save to the specified cache directory.
+// Use current package GOOS and GOARCH values for asset selection.
+// Install the asset atomically, so that interrupted downloads never leave incomplete plugin in cache.
+// If mirror is configured, download from mirror instead (GitHub release metadata is not used for verification then).
+//
+// Accept prebuilt plugin configuration, plugin version (without "v" prefix), cache directory to store plugin files and known archive checksums.
+// Return plugin executable path pointer, downloaded archive info pointer and error.
+func downloadBinaryPluginVersion(plugin BinaryPluginConfig, version, cacheDir string, checksums map[string]string) (*string, *ArchiveInfo, error) {
+	tag, asset, binary, err := getBinaryPluginFiles(plugin, version)
 	if err != nil {
-		return nil, fmt.Errorf("creating file '%s' error: %v", googleAPIsArchive, err)
+		return nil, nil, fmt.Errorf("error resolving %s asset: %v", plugin.Name, err)
 	} else {
-		defer out.Close()
-		defer os.Remove(googleAPIsArchive)
+		logrus.Debugf("Current %s asset: %s (release %s)", plugin.Name, asset, tag)
+	}
+
+	pluginDownloadUrl := fmt.Sprintf(RELEASE_TAG_BINARY_URL, getDownloadBase(plugin.Mirror, fmt.Sprintf(RELEASE_DOWNLOAD_BASE, plugin.Repository)), tag, asset)
+
+	logrus.Debugf("Downloading %s release: %s", plugin.Name, pluginDownloadUrl)
+	pluginReleaseInfo := ""
+	if plugin.Mirror == "" {
+		pluginReleaseInfo = fmt.Sprintf(RELEASE_INFO, plugin.Repository, tag)
+	}
+
+	expectedDigest, err := getExpectedDigest(checksums, asset, pluginReleaseInfo)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s asset verification error: %v", plugin.Name, err)
+	}
+
+	info, err := installArchive(pluginDownloadUrl, ArchiveInfo{Version: version, Asset: asset}, expectedDigest, cacheDir, "", binary)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s asset installation error: %v", plugin.Name, err)
+	} else {
+		logrus.Debugf("%s asset installed successfully to: %s", plugin.Name, cacheDir)
+	}
+
+	pluginExec := filepath.Join(cacheDir, binary)
+	return &pluginExec, info, nil
+}
+
+// Get release asset SHA-256 digest without installing the asset.
+// The digest is taken from GitHub release metadata (if release URL is given and the metadata contains it),
+// otherwise the asset is downloaded and hashed.
+//
+// Accept asset URL, asset name and release info URL (or empty string if there is no release).
+// Return hex-encoded asset SHA-256 digest and error.
+func getAssetDigest(url, asset, releaseURL string) (string, error) {
+	if releaseURL != "" {
+		digest, err := getReleaseAssetDigest(releaseURL, asset)
+		if err == nil {
+			return *digest, nil
+		} else {
+			logrus.Debugf("Digest for %s not found in release info, downloading it: %v", asset, err)
+		}
 	}
 
-	logrus.Debugf("Populating Google APIs library archive: %s", googleAPIsArchive)
-	n, err := io.Copy(out, resp.Body)
+	logrus.Infof("Downloading %s for checksum calculation: %s", asset, url)
+	resp, err := makeGETRequestToGitHubAPI(url, true)
 	if err != nil {
-		return nil, fmt.Errorf("response copying error: %v", err)
+		return "", fmt.Errorf("accessing URL '%s' error: %v", url, err)
 	} else {
-		logrus.Debugf("Downloaded file '%s' %d bytes successfully!", googleAPIsArchiveName, n)
+		defer resp.Body.Close()
+	}
+
+	hash := sha256.New()
+	_, err = io.Copy(hash, resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("response copying error: %v", err)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Get SHA-256 digests of the given release assets.
+// The digest of the already installed asset is reused, the assets, whose digests can not be found, are skipped with a warning.
+//
+// Accept tool name (for logging), installed archive info, asset names, release info URL (or empty string if there is no release) and function, making asset URL from asset name.
+// Return asset name to SHA-256 digest map.
+func getAssetsChecksums(name string, info ArchiveInfo, assets []string, releaseURL string, assetURL func(asset string) string) map[string]string {
+	checksums := make(map[string]string, len(assets))
+	for _, asset := range assets {
+		if asset == info.Asset && info.SHA256 != "" {
+			checksums[asset] = info.SHA256
+			continue
+		}
+
+		digest, err := getAssetDigest(assetURL(asset), asset, releaseURL)
+		if err != nil {
+			logrus.Warnf("Checksum of %s asset %s couldn't be found, it will not be locked: %v", name, asset, err)
+			continue
+		}
+		checksums[asset] = digest
 	}
+	return checksums
+}
 
-	logrus.Debugf("Unzipping Google APIs library archive: %s", googleAPIsArchive)
-	err = unzip(googleAPIsArchive, cacheDir)
+// Get release asset names, matching the given pattern.
+// If the release assets list can not be requested, no assets are returned (with a warning).
+//
+// Accept tool name (for logging), release info URL and asset name pattern (in [path.Match] format).
+// Return asset names list.
+func getMatchingReleaseAssets(name, releaseURL, pattern string) []string {
+	assets, err := getReleaseAssets(releaseURL)
 	if err != nil {
-		return nil, fmt.Errorf("Google APIs library archive unzipping error: %v", err)
+		logrus.Warnf("Release assets of %s couldn't be listed, only the installed one will be locked: %v", name, err)
+		return nil
+	}
+
+	var names []string
+	for _, asset := range assets {
+		if matched, _ := path.Match(pattern, asset.Name); matched {
+			names = append(names, asset.Name)
+		}
+	}
+	return names
+}
+
+// Get SHA-256 digests of protoc release assets for all the supported platforms.
+// For GitHub releases, all the platform assets are discovered from the release assets list,
+// for mirrors the default asset names of all the supported platforms are used.
+//
+// Accept installed protoc archive info and mirror base URL (or empty string if none).
+// Return asset name to SHA-256 digest map.
+func getProtocChecksums(info ArchiveInfo, mirror string) map[string]string {
+	var assets []string
+	releaseInfo := ""
+	if mirror == "" {
+		releaseInfo = fmt.Sprintf(PROTOC_RELEASE_INFO, info.Version)
+		assets = getMatchingReleaseAssets(PROTOC_EXECUTABLE, releaseInfo, fmt.Sprintf(PROTOC_ZIP_NAME, ANY_VERSION, ANY_PLATFORM))
 	} else {
-		logrus.Debugf("Google APIs library archive extracted successfully to: %s", cacheDir)
+		for _, target := range supportedPlatforms {
+			if platform, err := getProtocOSandArch(target.goos, target.goarch); err == nil {
+				assets = append(assets, fmt.Sprintf(PROTOC_ZIP_NAME, info.Version, *platform))
+			}
+		}
 	}
 
-	googleAPIsDir := filepath.Join(cacheDir, GOOGLEAPIS_DIR_NAME)
-	return &googleAPIsDir, nil
+	slices.Sort(assets)
+	assetURL := func(asset string) string {
+		return fmt.Sprintf(RELEASE_BINARY_URL, getDownloadBase(mirror, PROTOC_DOWNLOAD_BASE), info.Version, asset)
+	}
+	return getAssetsChecksums(PROTOC_EXECUTABLE, info, slices.Compact(assets), releaseInfo, assetURL)
+}
+
+// Get SHA-256 digests of flatc release assets for all the supported platforms (and all the linux distributions).
+// For GitHub releases, all the platform assets are discovered from the release assets list,
+// for mirrors the default asset names of all the supported platforms are used.
+//
+// Accept installed flatc archive info and mirror base URL (or empty string if none).
+// Return asset name to SHA-256 digest map.
+func getFlatcChecksums(info ArchiveInfo, mirror string) map[string]string {
+	var assets []string
+	releaseInfo := ""
+	if mirror == "" {
+		releaseInfo = fmt.Sprintf(FLATC_RELEASE_INFO, info.Version)
+		assets = getMatchingReleaseAssets(FLATC_EXECUTABLE, releaseInfo, fmt.Sprintf(FLATC_ZIP_NAME, ANY_PLATFORM, ANY_PLATFORM))
+	} else {
+		for _, target := range supportedPlatforms {
+			for _, distro := range flatcDistros {
+				if system, addition, err := getFlatcOSandAddition(target.goos, target.goarch, distro); err == nil {
+					assets = append(assets, fmt.Sprintf(FLATC_ZIP_NAME, *system, addition))
+				}
+			}
+		}
+	}
+
+	slices.Sort(assets)
+	assetURL := func(asset string) string {
+		return fmt.Sprintf(RELEASE_BINARY_URL, getDownloadBase(mirror, FLATC_DOWNLOAD_BASE), info.Version, asset)
+	}
+	return getAssetsChecksums(FLATC_EXECUTABLE, info, slices.Compact(assets), releaseInfo, assetURL)
+}
+
+// Get SHA-256 digests of prebuilt plugin release assets for all the configured platforms.
+//
+// Accept prebuilt plugin configuration and installed plugin archive info.
+// Return asset name to SHA-256 digest map.
+func getBinaryPluginChecksums(plugin BinaryPluginConfig, info ArchiveInfo) map[string]string {
+	version := strings.TrimPrefix(info.Version, "v")
+	tag, err := renderPluginTemplate(getBinaryPluginTagTemplate(plugin), version)
+	if err != nil {
+		logrus.Warnf("Release tag of %s couldn't be resolved, only the installed asset will be locked: %v", plugin.Name, err)
+		return nil
+	}
+
+	assets, err := getBinaryPluginAssets(plugin, version)
+	if err != nil {
+		logrus.Warnf("Release assets of %s couldn't be resolved, only the installed asset will be locked: %v", plugin.Name, err)
+		return nil
+	}
+
+	releaseInfo := ""
+	if plugin.Mirror == "" {
+		releaseInfo = fmt.Sprintf(RELEASE_INFO, plugin.Repository, tag)
+	}
+	assetURL := func(asset string) string {
+		return fmt.Sprintf(RELEASE_TAG_BINARY_URL, getDownloadBase(plugin.Mirror, fmt.Sprintf(RELEASE_DOWNLOAD_BASE, plugin.Repository)), tag, asset)
+	}
+	return getAssetsChecksums(plugin.Name, info, assets, releaseInfo, assetURL)
 }
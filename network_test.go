@@ -0,0 +1,201 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestGetExpectedDigest(t *testing.T) {
+	checksums := map[string]string{"protoc-25.1-linux-x86_64.zip": "sha256:ABCDEF", "flatc.zip": " 012345 "}
+
+	tests := []struct {
+		name     string
+		asset    string
+		required bool
+		expected string
+		fails    bool
+	}{
+		{name: "prefixed digest", asset: "protoc-25.1-linux-x86_64.zip", expected: "abcdef"},
+		{name: "plain digest", asset: "flatc.zip", expected: "012345"},
+		{name: "known digest required", asset: "flatc.zip", required: true, expected: "012345"},
+		{name: "unknown digest", asset: "googleapis.zip", expected: ""},
+		{name: "unknown digest required", asset: "googleapis.zip", required: true, fails: true},
+	}
+
+	defer configureChecksums(false)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configureChecksums(test.required)
+			digest, err := getExpectedDigest(checksums, test.asset, "")
+			if test.fails && err == nil {
+				t.Errorf("expected error, got digest '%s'", digest)
+			} else if !test.fails && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			} else if digest != test.expected {
+				t.Errorf("got digest '%s', expected '%s'", digest, test.expected)
+			}
+		})
+	}
+}
+
+func TestMakeGETRequestRateLimitWait(t *testing.T) {
+	tests := []struct {
+		name          string
+		retries       int
+		rateLimitWait time.Duration
+		limited       int
+		fails         bool
+	}{
+		{name: "rate limit wait without retries", retries: 0, rateLimitWait: time.Minute, limited: 1},
+		{name: "rate limit wait exceeds retries", retries: 1, rateLimitWait: time.Minute, limited: 2},
+		{name: "rate limit wait disabled", retries: 3, rateLimitWait: 0, limited: 1, fails: true},
+		{name: "rate limit wait exhausted", retries: 3, rateLimitWait: time.Second, limited: 2, fails: true},
+	}
+
+	defer configureNetwork(false, &defaultConfig().Network)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if requests <= test.limited {
+					w.Header().Set(RETRY_AFTER, "0")
+					w.WriteHeader(http.StatusTooManyRequests)
+				} else {
+					w.WriteHeader(http.StatusOK)
+				}
+			}))
+			defer server.Close()
+
+			config := defaultConfig().Network
+			config.Retries = test.retries
+			config.RateLimitWait = test.rateLimitWait
+			configureNetwork(false, &config)
+
+			res, err := makeGETRequestToGitHubAPI(server.URL, false)
+			if test.fails && err == nil {
+				res.Body.Close()
+				t.Errorf("expected error after %d requests", requests)
+			} else if !test.fails && err != nil {
+				t.Errorf("unexpected error after %d requests: %v", requests, err)
+			} else if err == nil {
+				res.Body.Close()
+			}
+		})
+	}
+}
+
+func TestInstallArchiveExecutable(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permission bits are not supported on windows")
+	}
+
+	var zipped bytes.Buffer
+	writer := zip.NewWriter(&zipped)
+	for _, name := range []string{"bin/tool", "include/tool.proto"} {
+		entry, err := writer.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entry.Write([]byte(name))
+	}
+	writer.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(zipped.Bytes())
+	}))
+	defer server.Close()
+
+	entryDir := filepath.Join(t.TempDir(), "tool-1.0.0")
+	_, err := installArchive(server.URL+"/tool.zip", ArchiveInfo{Version: "1.0.0", Asset: "tool.zip"}, "", entryDir, "", filepath.Join("bin", "tool"), "include")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(entryDir, "bin", "tool"))
+	if err != nil {
+		t.Fatal(err)
+	} else if info.Mode().Perm() != ARCHIVE_EXECUTABLE_MODE {
+		t.Errorf("executable mode %v, expected %v", info.Mode().Perm(), os.FileMode(ARCHIVE_EXECUTABLE_MODE))
+	}
+
+	_, err = installArchive(server.URL+"/tool.zip", ArchiveInfo{Version: "1.0.0", Asset: "tool.zip"}, "", entryDir+"-missing", "", filepath.Join("bin", "missing"))
+	if err == nil {
+		t.Error("expected error for missing executable")
+	}
+}
+
+func TestCompareAssetNames(t *testing.T) {
+	tests := []struct {
+		a        string
+		b        string
+		expected int
+	}{
+		{a: "Linux.flatc.binary.g++-13.zip", b: "Linux.flatc.binary.g++-13.zip", expected: 0},
+		{a: "Linux.flatc.binary.g++-9.zip", b: "Linux.flatc.binary.g++-13.zip", expected: -1},
+		{a: "Linux.flatc.binary.clang++-18.zip", b: "Linux.flatc.binary.clang++-15.zip", expected: 1},
+		{a: "protoc-25.10-linux-x86_64.zip", b: "protoc-25.9-linux-x86_64.zip", expected: 1},
+		{a: "Mac.flatc.binary.zip", b: "MacIntel.flatc.binary.zip", expected: -1},
+		{a: "flatc", b: "flatc.zip", expected: -1},
+	}
+
+	for _, test := range tests {
+		if actual := compareAssetNames(test.a, test.b); actual != test.expected {
+			t.Errorf("compareAssetNames(%s, %s) = %d, expected %d", test.a, test.b, actual, test.expected)
+		}
+		if actual := compareAssetNames(test.b, test.a); actual != -test.expected {
+			t.Errorf("compareAssetNames(%s, %s) = %d, expected %d", test.b, test.a, actual, -test.expected)
+		}
+	}
+}
+
+func TestFindReleaseAsset(t *testing.T) {
+	releaseURL := "https://api.github.com/repos/google/flatbuffers/releases/tags/v24.3.25"
+	releaseAssetsCache[releaseURL] = []releaseAsset{
+		{Name: "Linux.flatc.binary.clang++-15.zip"},
+		{Name: "Linux.flatc.binary.g++-9.zip"},
+		{Name: "Linux.flatc.binary.g++-13.zip"},
+		{Name: "Linux.flatc.binary.g++-10.zip"},
+		{Name: "MacIntel.flatc.binary.zip"},
+		{Name: "Windows.flatc.binary.zip"},
+	}
+	defer delete(releaseAssetsCache, releaseURL)
+
+	gcc := fmt.Sprintf(FLATC_ZIP_NAME, LINUX_ANY, ADDITION_ANY_GCC)
+	clang := fmt.Sprintf(FLATC_ZIP_NAME, LINUX_ANY, ADDITION_ANY_CLANG)
+	mac := fmt.Sprintf(FLATC_ZIP_NAME, MAC, "")
+	macIntel := fmt.Sprintf(FLATC_ZIP_NAME, MAC_INTEL, "")
+
+	tests := []struct {
+		name     string
+		patterns []string
+		expected string
+		fails    bool
+	}{
+		{name: "highest compiler version", patterns: []string{gcc, clang}, expected: "Linux.flatc.binary.g++-13.zip"},
+		{name: "preferred distribution", patterns: []string{clang, gcc}, expected: "Linux.flatc.binary.clang++-15.zip"},
+		{name: "compatible fallback", patterns: []string{mac, macIntel}, expected: "MacIntel.flatc.binary.zip"},
+		{name: "no matching asset", patterns: []string{mac}, fails: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			asset, err := findReleaseAsset(releaseURL, test.patterns)
+			if test.fails && err == nil {
+				t.Errorf("expected error, got asset %s", asset)
+			} else if !test.fails && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			} else if asset != test.expected {
+				t.Errorf("got asset %s, expected %s", asset, test.expected)
+			}
+		})
+	}
+}
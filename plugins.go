@@ -0,0 +1,446 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	PROTOBUF_MODULE             = "google.golang.org/protobuf"
+	GRPC_MODULE                 = "google.golang.org/grpc"
+	PROTOC_GEN_GO_GRPC_MODULE   = PROTOC_GEN_GO_GRPC_PREFIX + "/" + PROTOC_GEN_GO_GRPC_PACKAGE
+	PLUGIN_VERSION_AUTO         = ""
+	PLUGIN_VERSION_LATEST       = "latest"
+	PLUGIN_VERSION_DEVEL        = "(devel)"
+	PROTOC_GEN_GO_GRPC_FALLBACK = "v1.2.0"
+	PLUGINS_CACHE_DIR           = "plugins"
+	PLUGIN_CACHE_DELIMITER      = CACHE_ENTRY_DELIMITER + "v"
+	DEFAULT_RELEASE_TAG         = "v{{.Version}}"
+	PLATFORM_NAME               = "%s/%s"
+	TEMPLATE_VERSION_SEPARATOR  = "\x00"
+	PROTOBUF_GO_REPOSITORY      = "protocolbuffers/protobuf-go"
+	PROTOC_PLUGIN_PREFIX        = "protoc-gen-"
+	PLUGIN_ARGUMENT             = "--plugin=%s=%s"
+)
+
+// Compatibility of "protoc-gen-go-grpc" versions with gRPC runtime versions.
+// Generated code requires gRPC runtime of at least the given version, entries are sorted by runtime version in descending order.
+var protocGenGoGrpcCompatibility = []struct {
+	runtime string
+	plugin  string
+}{
+	{runtime: "v1.64.0", plugin: "v1.5.1"},
+	{runtime: "v1.62.0", plugin: "v1.4.0"},
+	{runtime: "v1.52.0", plugin: "v1.3.0"},
+}
+
+// Prebuilt "protoc-gen-go" release assets, published by protobuf-go project.
+var protocGenGoRelease = BinaryPluginConfig{
+	Name:       PROTOC_GEN_GO_PACKAGE,
+	Repository: PROTOBUF_GO_REPOSITORY,
+	Assets: map[string]string{
+		"darwin/amd64":  "protoc-gen-go.v{{.Version}}.darwin.amd64.tar.gz",
+		"darwin/arm64":  "protoc-gen-go.v{{.Version}}.darwin.arm64.tar.gz",
+		"linux/386":     "protoc-gen-go.v{{.Version}}.linux.386.tar.gz",
+		"linux/amd64":   "protoc-gen-go.v{{.Version}}.linux.amd64.tar.gz",
+		"linux/arm64":   "protoc-gen-go.v{{.Version}}.linux.arm64.tar.gz",
+		"windows/386":   "protoc-gen-go.v{{.Version}}.windows.386.zip",
+		"windows/amd64": "protoc-gen-go.v{{.Version}}.windows.amd64.zip",
+	},
+}
+
+// Get plugin cache directory name prefix, the directory name is "[NAME]@v[VERSION]".
+//
+// Accept plugin (command) name.
+// Return plugin cache directory name prefix.
+func getPluginCachePrefix(name string) string {
+	return name + PLUGIN_CACHE_DELIMITER
+}
+
+// Derive "protoc-gen-go" version from GO module requirements.
+// The plugin is a part of protobuf runtime module, so their versions are the same.
+//
+// Accept GO module requirements (module path to version map).
+// Return plugin version and boolean flag, whether the version could be derived.
+func deriveProtocGenGoVersion(requirements map[string]string) (string, bool) {
+	version, ok := requirements[PROTOBUF_MODULE]
+	return version, ok
+}
+
+// Derive "protoc-gen-go-grpc" version from GO module requirements.
+// The plugin is a separate module, so it is used if required explicitly.
+// Otherwise, the newest plugin version, compatible with the required gRPC runtime version, is chosen.
+//
+// Accept GO module requirements (module path to version map).
+// Return plugin version and boolean flag, whether the version could be derived.
+func deriveProtocGenGoGrpcVersion(requirements map[string]string) (string, bool) {
+	if version, ok := requirements[PROTOC_GEN_GO_GRPC_MODULE]; ok {
+		return version, true
+	}
+
+	runtime, ok := requirements[GRPC_MODULE]
+	if !ok {
+		return "", false
+	}
+
+	for _, entry := range protocGenGoGrpcCompatibility {
+		if compareVersions(runtime, entry.runtime) >= 0 {
+			return entry.plugin, true
+		}
+	}
+	return PROTOC_GEN_GO_GRPC_FALLBACK, true
+}
+
+// Validate configured plugins.
+// Every plugin should have either name of a built-in plugin or GO package path, the name (if specified) should match the last package path element.
+//
+// Accept configuration pointer.
+// Return error.
+func validatePluginConfigs(config *Config) error {
+	for _, plugin := range config.Protoc.Plugins {
+		if plugin.Package == "" && !slices.ContainsFunc(protocGoPlugins, func(builtin goPackage) bool { return builtin.name == plugin.Name }) {
+			return fmt.Errorf("plugin '%s' is not built-in, so its package should be specified", plugin.Name)
+		} else if plugin.Package != "" && plugin.Name != "" && plugin.Name != path.Base(plugin.Package) {
+			return fmt.Errorf("plugin name '%s' doesn't match its package '%s' command name", plugin.Name, plugin.Package)
+		}
+	}
+	return nil
+}
+
+// Validate configured prebuilt plugins.
+// Every plugin should have a name, a repository or a mirror and at least one asset.
+//
+// Accept configuration pointer.
+// Return error.
+func validateBinaryPluginConfigs(config *Config) error {
+	for _, plugin := range config.Protoc.BinaryPlugins {
+		if plugin.Name == "" {
+			return fmt.Errorf("prebuilt plugin name should be specified")
+		} else if plugin.Repository == "" && plugin.Mirror == "" {
+			return fmt.Errorf("prebuilt plugin '%s' should have either repository or mirror specified", plugin.Name)
+		} else if len(plugin.Assets) == 0 {
+			return fmt.Errorf("prebuilt plugin '%s' should have at least one asset specified", plugin.Name)
+		}
+	}
+	return nil
+}
+
+// Render prebuilt plugin template, replacing "{{.Version}}" with the plugin version.
+//
+// Accept template string and plugin version (without "v" prefix).
+// Return rendered string and error.
+func renderPluginTemplate(text, version string) (string, error) {
+	parsed, err := template.New("plugin").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("error parsing template '%s': %v", text, err)
+	}
+
+	var builder strings.Builder
+	err = parsed.Execute(&builder, struct{ Version string }{Version: version})
+	if err != nil {
+		return "", fmt.Errorf("error rendering template '%s': %v", text, err)
+	}
+
+	return builder.String(), nil
+}
+
+// Get requested prebuilt plugin version, "latest" is used if no version is configured.
+//
+// Accept prebuilt plugin configuration.
+// Return requested version.
+func getBinaryPluginVersion(plugin BinaryPluginConfig) string {
+	if plugin.Version == PLUGIN_VERSION_AUTO {
+		return PLUGIN_VERSION_LATEST
+	} else {
+		return plugin.Version
+	}
+}
+
+// Get prebuilt plugin release tag template.
+//
+// Accept prebuilt plugin configuration.
+// Return release tag template.
+func getBinaryPluginTagTemplate(plugin BinaryPluginConfig) string {
+	if plugin.Tag == "" {
+		return DEFAULT_RELEASE_TAG
+	} else {
+		return plugin.Tag
+	}
+}
+
+// Extract version from prebuilt plugin release tag, removing the parts of tag template around "{{.Version}}".
+//
+// Accept prebuilt plugin configuration and release tag.
+// Return plugin version.
+func getBinaryPluginVersionFromTag(plugin BinaryPluginConfig, tag string) string {
+	rendered, err := renderPluginTemplate(getBinaryPluginTagTemplate(plugin), TEMPLATE_VERSION_SEPARATOR)
+	if err != nil {
+		return tag
+	}
+
+	prefix, suffix, _ := strings.Cut(rendered, TEMPLATE_VERSION_SEPARATOR)
+	return strings.TrimSuffix(strings.TrimPrefix(tag, prefix), suffix)
+}
+
+// Get prebuilt plugin release tag, asset name and executable path (relative to the cache entry) for the current platform.
+// If the asset is not an archive, the asset itself is the executable.
+//
+// Accept prebuilt plugin configuration and version (without "v" prefix).
+// Return release tag, asset name, executable path and error.
+func getBinaryPluginFiles(plugin BinaryPluginConfig, version string) (string, string, string, error) {
+	platform := fmt.Sprintf(PLATFORM_NAME, runtime.GOOS, runtime.GOARCH)
+	assetTemplate, ok := plugin.Assets[platform]
+	if !ok {
+		var platforms []string
+		for available := range plugin.Assets {
+			platforms = append(platforms, available)
+		}
+		slices.Sort(platforms)
+		return "", "", "", fmt.Errorf("prebuilt plugin '%s' has no asset for platform %s (available: %s)", plugin.Name, platform, strings.Join(platforms, ", "))
+	}
+
+	tag, err := renderPluginTemplate(getBinaryPluginTagTemplate(plugin), version)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	asset, err := renderPluginTemplate(assetTemplate, version)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	if !isArchiveAsset(asset) {
+		return tag, asset, asset, nil
+	} else if plugin.Binary == "" {
+		return tag, asset, getExecutableName(plugin.Name), nil
+	}
+
+	binary, err := renderPluginTemplate(plugin.Binary, version)
+	if err != nil {
+		return "", "", "", err
+	}
+	return tag, asset, binary, nil
+}
+
+// Get prebuilt plugin release asset names for all the configured platforms.
+//
+// Accept prebuilt plugin configuration and version (without "v" prefix).
+// Return sorted asset names list and error.
+func getBinaryPluginAssets(plugin BinaryPluginConfig, version string) ([]string, error) {
+	var assets []string
+	for _, assetTemplate := range plugin.Assets {
+		asset, err := renderPluginTemplate(assetTemplate, version)
+		if err != nil {
+			return nil, err
+		}
+		assets = append(assets, asset)
+	}
+
+	slices.Sort(assets)
+	return slices.Compact(assets), nil
+}
+
+// Get all the GO plugins, required for protobuf compiler: built-in and configured ones.
+// Configured plugins, having the same name as built-in ones, override built-in plugin packages (their versions are not derived from GO module requirements then).
+// If prebuilt plugins are disabled in configuration, all the plugins are installed with "go install".
+// Configuration is expected to be validated with "validatePluginConfigs".
+//
+// Accept configuration pointer.
+// Return plugins list.
+func getProtocPlugins(config *Config) []goPackage {
+	plugins := slices.Clone(protocGoPlugins)
+	for _, plugin := range config.Protoc.Plugins {
+		name := plugin.Name
+		if name == "" {
+			name = path.Base(plugin.Package)
+		}
+
+		index := slices.IndexFunc(plugins, func(builtin goPackage) bool { return builtin.name == name })
+		if plugin.Package == "" {
+			continue
+		} else if index == -1 {
+			plugins = append(plugins, goPackage{prefix: path.Dir(plugin.Package), name: name})
+		} else if plugins[index].prefix != path.Dir(plugin.Package) {
+			plugins[index] = goPackage{prefix: path.Dir(plugin.Package), name: name}
+		}
+	}
+
+	if !config.Protoc.PrebuiltPlugins {
+		for i := range plugins {
+			plugins[i].release = nil
+		}
+	}
+	return plugins
+}
+
+// Get protobuf compiler plugins, declared as tools in the current GO module (all the tools with "protoc-gen-" command name prefix).
+// These plugins are built by GO with versions from "go.mod" and "go.sum", so they are never installed to cache.
+//
+// Accept GO executable path.
+// Return plugin (command) name to package path map and error.
+func getProtocToolPlugins(goExecutable string) (map[string]string, error) {
+	tools, err := getGoModTools(goExecutable)
+	if err != nil {
+		return nil, err
+	}
+
+	plugins := make(map[string]string)
+	for name, toolPackage := range tools {
+		if strings.HasPrefix(name, PROTOC_PLUGIN_PREFIX) {
+			plugins[name] = toolPackage
+		}
+	}
+	return plugins, nil
+}
+
+// Make explicit protobuf compiler plugin argument ("--plugin=[NAME]=[PATH]") with absolute plugin executable path.
+// Explicit plugin path is used instead of compiler PATH lookup, so that no other plugin with the same name (e.g. installed system-wide) can shadow the managed one.
+// Log the resolved plugin executable and the shadowed one (if any).
+//
+// Accept plugin (command) name and plugin executable path.
+// Return compiler plugin argument and error.
+func makePluginArgument(name, executable string) (string, error) {
+	absoluteExecutable, err := filepath.Abs(executable)
+	if err != nil {
+		return "", fmt.Errorf("error resolving plugin %s executable path %s: %v", name, executable, err)
+	}
+
+	logrus.Infof("Plugin %s resolved to: %s", name, absoluteExecutable)
+	if shadowed, err := exec.LookPath(name); err == nil {
+		logrus.Infof("Plugin %s found in PATH is not used: %s", name, shadowed)
+	}
+
+	return fmt.Sprintf(PLUGIN_ARGUMENT, name, absoluteExecutable), nil
+}
+
+// Warn about compiler plugins, that are not managed by "protogo" and are only found in GO binary location (GOBIN).
+// Plugins are requested with "--[NAME]_out" compiler arguments, the ones passed explicitly with "--plugin=..." arguments are managed.
+// GOBIN is appended to compiler PATH, so that such plugins (e.g. installed with "go install") are still found by the compiler.
+//
+// Accept compiler arguments (including plugin arguments) and GO binary location.
+func warnUnmanagedPlugins(compilerArgs []string, goBin string) {
+	managed := make(map[string]bool)
+	for _, arg := range compilerArgs {
+		if value, ok := strings.CutPrefix(arg, "--plugin="); ok {
+			name, _, found := strings.Cut(value, "=")
+			if !found {
+				name = strings.TrimSuffix(filepath.Base(value), filepath.Ext(value))
+			}
+			managed[name] = true
+		}
+	}
+
+	for _, arg := range compilerArgs {
+		flag, _, _ := strings.Cut(arg, "=")
+		language, ok := strings.CutSuffix(strings.TrimPrefix(flag, "--"), "_out")
+		if !ok || !strings.HasPrefix(flag, "--") || managed[PROTOC_PLUGIN_PREFIX+language] {
+			continue
+		}
+
+		name := PROTOC_PLUGIN_PREFIX + language
+		managed[name] = true
+		if _, err := exec.LookPath(name); err == nil {
+			continue
+		}
+
+		executable := filepath.Join(goBin, getExecutableName(name))
+		if _, err := os.Stat(executable); err == nil {
+			logrus.Warnf("Plugin %s is not managed by protogo, using the one found in GOBIN: %s (add it to 'plugins' config section or go.mod tools to pin its version)", name, executable)
+		}
+	}
+}
+
+// Find configured plugin.
+//
+// Accept configuration pointer and plugin (command) name.
+// Return plugin configuration pointer (or nil if the plugin is not configured).
+func findPluginConfig(config *Config, name string) *PluginConfig {
+	for i := range config.Protoc.Plugins {
+		if config.Protoc.Plugins[i].Name == name || (config.Protoc.Plugins[i].Name == "" && path.Base(config.Protoc.Plugins[i].Package) == name) {
+			return &config.Protoc.Plugins[i]
+		}
+	}
+	return nil
+}
+
+// Set configured plugin version, adding the plugin to configuration if it is not there.
+//
+// Accept configuration pointer, plugin (command) name and version.
+func setPluginVersion(config *Config, name, version string) {
+	if plugin := findPluginConfig(config, name); plugin != nil {
+		plugin.Version = version
+	} else {
+		config.Protoc.Plugins = append(config.Protoc.Plugins, PluginConfig{Name: name, Version: version})
+	}
+}
+
+// Check whether GO plugin version is a full module version (e.g. "v1.34.2" or "v1.0.0-rc.1"),
+// rather than a version query, resolved by "go install" ("latest" or a version prefix, e.g. "v1.34").
+//
+// Accept plugin version string.
+// Return true if the version is a full module version.
+func isFullPluginVersion(version string) bool {
+	return len(strings.Split(strings.TrimPrefix(version, "v"), ".")) >= 3
+}
+
+// Check whether GO plugin version matches the requested version or version query.
+// "latest" query is matched by any version, version prefix (e.g. "v1.34") is matched by the versions, starting with it (e.g. "v1.34.2").
+//
+// Accept plugin version and requested version (or version query).
+// Return true if the version matches.
+func matchesPluginVersion(version, requested string) bool {
+	version, requested = strings.TrimPrefix(version, "v"), strings.TrimPrefix(requested, "v")
+	return requested == PLUGIN_VERSION_LATEST || version == requested || strings.HasPrefix(version, requested+".")
+}
+
+// Choose GO plugin version to use.
+// Precedence is the following: configured version > locked version > version derived from GO module requirements > "latest".
+// Locked version is used if "latest" (or any other version alias), exactly the locked version or its prefix (e.g. "v1.34") is configured.
+// If another version is configured explicitly, the lock is ignored (with a warning).
+//
+// Accept plugin, configuration pointer, lock pointer and GO module requirements (module path to version map).
+// Return version to use.
+func resolvePluginVersion(plugin goPackage, config *Config, lock *Lock, requirements map[string]string) string {
+	configured := PLUGIN_VERSION_AUTO
+	if pluginConfig := findPluginConfig(config, plugin.name); pluginConfig != nil {
+		configured = pluginConfig.Version
+	}
+
+	locked, isLocked := lock.Plugins[plugin.name]
+	derived, isDerived := "", false
+	if plugin.derive != nil {
+		derived, isDerived = plugin.derive(requirements)
+	}
+
+	if configured != PLUGIN_VERSION_AUTO && isLocked && (slices.Contains(versionAliases, configured) || matchesPluginVersion(locked.Version, configured)) {
+		logrus.Debugf("Using %s version from lock file: %s (configured %s)", plugin.name, locked.Version, configured)
+		return locked.Version
+	} else if configured != PLUGIN_VERSION_AUTO {
+		if isLocked {
+			logrus.Warnf("Requested %s version %s doesn't match locked version %s, lock is ignored (run 'protogo lock' to update it)", plugin.name, configured, locked.Version)
+		}
+		logrus.Debugf("Using configured %s version: %s", plugin.name, configured)
+		return configured
+	} else if isLocked {
+		if isDerived && strings.TrimPrefix(derived, "v") != strings.TrimPrefix(locked.Version, "v") {
+			logrus.Warnf("Locked %s version %s doesn't match version %s, derived from go.mod (run 'protogo lock' to update it)", plugin.name, locked.Version, derived)
+		}
+		logrus.Debugf("Using %s version from lock file: %s", plugin.name, locked.Version)
+		return locked.Version
+	} else if isDerived {
+		logrus.Debugf("Using %s version derived from go.mod: %s", plugin.name, derived)
+		return derived
+	} else {
+		logrus.Debugf("Using latest %s version", plugin.name)
+		return PLUGIN_VERSION_LATEST
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestMatchesPluginVersion(t *testing.T) {
+	tests := []struct {
+		version   string
+		requested string
+		full      bool
+		expected  bool
+	}{
+		{version: "v1.34.2", requested: "v1.34.2", full: true, expected: true},
+		{version: "1.34.2", requested: "v1.34.2", full: true, expected: true},
+		{version: "v1.34.2", requested: "v1.34", expected: true},
+		{version: "v1.34.2", requested: "v1", expected: true},
+		{version: "v1.34.2", requested: "v1.3", expected: false},
+		{version: "v1.34.2", requested: "latest", expected: true},
+		{version: "v1.0.0-rc.1", requested: "v1.0.0-rc.1", full: true, expected: true},
+		{version: "v1.35.0", requested: "v1.34.2", full: true, expected: false},
+	}
+
+	for _, test := range tests {
+		if actual := matchesPluginVersion(test.version, test.requested); actual != test.expected {
+			t.Errorf("matchesPluginVersion(%s, %s) = %v, expected %v", test.version, test.requested, actual, test.expected)
+		}
+		if actual := isFullPluginVersion(test.requested); actual != test.full {
+			t.Errorf("isFullPluginVersion(%s) = %v, expected %v", test.requested, actual, test.full)
+		}
+	}
+}
+
+func TestResolvePluginVersion(t *testing.T) {
+	derive := func(requirements map[string]string) (string, bool) {
+		version, ok := requirements[PROTOBUF_MODULE]
+		return version, ok
+	}
+	plugin := goPackage{prefix: PROTOC_GEN_GO_PREFIX, name: PROTOC_GEN_GO_PACKAGE, derive: derive}
+	locked := &Lock{Plugins: map[string]PluginInfo{PROTOC_GEN_GO_PACKAGE: {Version: "v1.34.2"}}}
+	derived := map[string]string{PROTOBUF_MODULE: "v1.33.0"}
+
+	tests := []struct {
+		name         string
+		configured   string
+		lock         *Lock
+		requirements map[string]string
+		expected     string
+	}{
+		{name: "nothing known", configured: PLUGIN_VERSION_AUTO, lock: &Lock{}, expected: PLUGIN_VERSION_LATEST},
+		{name: "derived", configured: PLUGIN_VERSION_AUTO, lock: &Lock{}, requirements: derived, expected: "v1.33.0"},
+		{name: "locked over derived", configured: PLUGIN_VERSION_AUTO, lock: locked, requirements: derived, expected: "v1.34.2"},
+		{name: "configured over derived", configured: "v1.32.0", lock: &Lock{}, requirements: derived, expected: "v1.32.0"},
+		{name: "configured latest without lock", configured: PLUGIN_VERSION_LATEST, lock: &Lock{}, expected: PLUGIN_VERSION_LATEST},
+		{name: "configured latest is locked", configured: PLUGIN_VERSION_LATEST, lock: locked, expected: "v1.34.2"},
+		{name: "configured alias is locked", configured: VERSION_ALIAS_LATEST_RC, lock: locked, expected: "v1.34.2"},
+		{name: "configured locked version", configured: "1.34.2", lock: locked, expected: "v1.34.2"},
+		{name: "configured prefix is locked", configured: "v1.34", lock: locked, requirements: derived, expected: "v1.34.2"},
+		{name: "configured other version ignores lock", configured: "v1.35.0", lock: locked, expected: "v1.35.0"},
+		{name: "configured other prefix ignores lock", configured: "v1.3", lock: locked, expected: "v1.3"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := defaultConfig()
+			setPluginVersion(config, PROTOC_GEN_GO_PACKAGE, test.configured)
+			if actual := resolvePluginVersion(plugin, config, test.lock, test.requirements); actual != test.expected {
+				t.Errorf("got version %s, expected %s", actual, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	VERSION_CONSTRAINT_CHARACTERS = "~^<>=,*"
+	VERSION_CONSTRAINT_DELIMITER  = ","
+
+	VERSION_ALIAS_LATEST         = "latest"
+	VERSION_ALIAS_LATEST_RC      = "latest-rc"
+	VERSION_ALIAS_PREVIOUS_MAJOR = "previous-major"
+)
+
+// Version aliases, resolved against the list of available versions.
+var versionAliases = []string{VERSION_ALIAS_LATEST, VERSION_ALIAS_LATEST_RC, VERSION_ALIAS_PREVIOUS_MAJOR}
+
+// Version comparison operators, allowed in constraints (empty operator means exact match).
+var versionOperators = []string{"", "=", ">", ">=", "<", "<="}
+
+// Split version component into numeric prefix and the remaining suffix.
+// E.g. "0-rc1" is split into 0 and "-rc1".
+//
+// Accept version component string.
+// Return numeric prefix (or -1 if there is none) and suffix.
+func splitVersionComponent(component string) (int, string) {
+	end := 0
+	for end < len(component) && component[end] >= '0' && component[end] <= '9' {
+		end++
+	}
+
+	number, err := strconv.Atoi(component[:end])
+	if err != nil {
+		return -1, component
+	}
+
+	return number, component[end:]
+}
+
+// Compare two versions (with or without "v" prefix), component by component.
+// Numeric components are compared as numbers, release versions are considered greater than pre-release versions (e.g. "25.0" > "25.0-rc1").
+//
+// Accept two version strings.
+// Return negative number if the first version is lower, positive if it is greater and zero if they are equal.
+func compareVersions(a, b string) int {
+	aComponents := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bComponents := strings.Split(strings.TrimPrefix(b, "v"), ".")
+
+	for i := 0; i < len(aComponents) || i < len(bComponents); i++ {
+		if i >= len(aComponents) {
+			return -1
+		} else if i >= len(bComponents) {
+			return 1
+		}
+
+		aNumber, aSuffix := splitVersionComponent(aComponents[i])
+		bNumber, bSuffix := splitVersionComponent(bComponents[i])
+		if aNumber != bNumber {
+			return aNumber - bNumber
+		} else if aSuffix == bSuffix {
+			continue
+		} else if aSuffix == "" {
+			return 1
+		} else if bSuffix == "" {
+			return -1
+		} else if comparison := compareVersionSuffixes(aSuffix, bSuffix); comparison != 0 {
+			return comparison
+		}
+	}
+
+	return 0
+}
+
+// Compare two pre-release version suffixes, numeric parts are compared as numbers (e.g. "-rc10" > "-rc2").
+//
+// Accept two version suffix strings.
+// Return negative number if the first suffix is lower, positive if it is greater and zero if they are equal.
+func compareVersionSuffixes(a, b string) int {
+	for a != "" || b != "" {
+		aEnd, bEnd := len(a), len(b)
+		if index := strings.IndexAny(a, "0123456789"); index != -1 {
+			aEnd = index
+		}
+		if index := strings.IndexAny(b, "0123456789"); index != -1 {
+			bEnd = index
+		}
+
+		if comparison := strings.Compare(a[:aEnd], b[:bEnd]); comparison != 0 {
+			return comparison
+		}
+
+		aNumber, aRest := splitVersionComponent(a[aEnd:])
+		bNumber, bRest := splitVersionComponent(b[bEnd:])
+		if aNumber != bNumber {
+			return aNumber - bNumber
+		}
+		a, b = aRest, bRest
+	}
+
+	return 0
+}
+
+// Single version constraint, e.g. ">=23" or "<26".
+type versionConstraint struct {
+	operator string
+	version  string
+}
+
+// Check whether requested version is a constraint expression (e.g. "~25.1", "^24", ">=23,<26" or "25.x") rather than an exact version.
+//
+// Accept requested version string.
+// Return true if the version is a constraint expression.
+func isVersionConstraint(version string) bool {
+	if strings.ContainsAny(version, VERSION_CONSTRAINT_CHARACTERS) {
+		return true
+	}
+
+	for _, component := range strings.Split(version, ".") {
+		if isVersionWildcard(component) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check whether version component is a wildcard ("x", "X" or "*").
+//
+// Accept version component string.
+// Return true if the component is a wildcard.
+func isVersionWildcard(component string) bool {
+	return component == "x" || component == "X" || component == "*"
+}
+
+// Check whether version is a pre-release version, i.e. whether any of its components is not a number (e.g. "25.0-rc1").
+//
+// Accept version string (with or without "v" prefix).
+// Return true if the version is a pre-release version.
+func isPreReleaseVersion(version string) bool {
+	for _, component := range strings.Split(strings.TrimPrefix(version, "v"), ".") {
+		if number, suffix := splitVersionComponent(component); number == -1 || suffix != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse numeric version (with or without "v" prefix) into components.
+//
+// Accept version string.
+// Return version components and error.
+func parseVersionNumbers(version string) ([]int, error) {
+	var numbers []int
+	for _, component := range strings.Split(strings.TrimPrefix(version, "v"), ".") {
+		number, err := strconv.Atoi(component)
+		if err != nil || number < 0 {
+			return nil, fmt.Errorf("invalid version '%s'", version)
+		}
+		numbers = append(numbers, number)
+	}
+	return numbers, nil
+}
+
+// Make upper version bound: keep the version components before the given index and increment the component at the index.
+// E.g. components [25, 1, 3] and index 1 produce "25.2".
+//
+// Accept version components and index of the component to increment.
+// Return upper bound version string.
+func bumpVersion(numbers []int, index int) string {
+	components := make([]string, index+1)
+	for i := 0; i < index; i++ {
+		components[i] = strconv.Itoa(numbers[i])
+	}
+	components[index] = strconv.Itoa(numbers[index] + 1)
+	return strings.Join(components, ".")
+}
+
+// Parse version constraint expression.
+// Expression consists of comma-separated constraints, that should all be satisfied:
+//   - "~25.1" allows patch updates (">=25.1,<25.2"), "~25" allows minor updates (">=25,<26")
+//   - "^24.1" allows updates that don't change the first non-zero component (">=24.1,<25")
+//   - "25.x" (or "25.*") allows any version with the given prefix (">=25,<26")
+//   - ">=23", ">23", "<=26", "<26" and "=25.1" compare versions directly
+//
+// Accept constraint expression.
+// Return constraints list and error.
+func parseVersionConstraints(expression string) ([]versionConstraint, error) {
+	var constraints []versionConstraint
+	for _, part := range strings.Split(expression, VERSION_CONSTRAINT_DELIMITER) {
+		part = strings.ReplaceAll(part, " ", "")
+		if part == "" {
+			return nil, fmt.Errorf("empty constraint in '%s'", expression)
+		}
+
+		switch {
+		case strings.HasPrefix(part, "~") || strings.HasPrefix(part, "^"):
+			numbers, err := parseVersionNumbers(part[1:])
+			if err != nil {
+				return nil, err
+			}
+
+			index := 0
+			if part[0] == '~' && len(numbers) > 1 {
+				index = 1
+			} else if part[0] == '^' {
+				for index < len(numbers)-1 && numbers[index] == 0 {
+					index++
+				}
+			}
+			constraints = append(constraints, versionConstraint{operator: ">=", version: part[1:]}, versionConstraint{operator: "<", version: bumpVersion(numbers, index)})
+
+		case isVersionWildcard(part[len(part)-1:]):
+			prefix := strings.TrimRight(part[:len(part)-1], ".")
+			if prefix == "" {
+				continue
+			}
+
+			numbers, err := parseVersionNumbers(prefix)
+			if err != nil {
+				return nil, err
+			}
+			constraints = append(constraints, versionConstraint{operator: ">=", version: prefix}, versionConstraint{operator: "<", version: bumpVersion(numbers, len(numbers)-1)})
+
+		default:
+			operator := strings.TrimRight(part, "v0123456789.")
+			if !slices.Contains(versionOperators, operator) {
+				return nil, fmt.Errorf("invalid constraint '%s'", part)
+			} else if operator == "" {
+				operator = "="
+			}
+
+			_, err := parseVersionNumbers(strings.TrimPrefix(part, operator))
+			if err != nil {
+				return nil, err
+			}
+			constraints = append(constraints, versionConstraint{operator: operator, version: strings.TrimPrefix(part, operator)})
+		}
+	}
+	return constraints, nil
+}
+
+// Compare two versions, treating missing components as zeros (e.g. "25" is equal to "25.0").
+//
+// Accept two version strings.
+// Return negative number if the first version is lower, positive if it is greater and zero if they are equal.
+func comparePaddedVersions(a, b string) int {
+	aComponents := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bComponents := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for len(aComponents) < len(bComponents) {
+		aComponents = append(aComponents, "0")
+	}
+	for len(bComponents) < len(aComponents) {
+		bComponents = append(bComponents, "0")
+	}
+	return compareVersions(strings.Join(aComponents, "."), strings.Join(bComponents, "."))
+}
+
+// Check whether version satisfies all the constraints.
+// Pre-release versions never satisfy constraints.
+//
+// Accept version string and constraints list.
+// Return true if the version satisfies all the constraints.
+func matchesVersionConstraints(version string, constraints []versionConstraint) bool {
+	if isPreReleaseVersion(version) {
+		return false
+	}
+
+	for _, constraint := range constraints {
+		comparison := comparePaddedVersions(version, constraint.version)
+		switch constraint.operator {
+		case "=":
+			if comparison != 0 {
+				return false
+			}
+		case ">":
+			if comparison <= 0 {
+				return false
+			}
+		case ">=":
+			if comparison < 0 {
+				return false
+			}
+		case "<":
+			if comparison >= 0 {
+				return false
+			}
+		case "<=":
+			if comparison > 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Check whether version satisfies constraint expression.
+// Invalid expressions are never satisfied.
+//
+// Accept version string and constraint expression.
+// Return true if the version satisfies the expression.
+func matchesVersionExpression(version, expression string) bool {
+	constraints, err := parseVersionConstraints(expression)
+	return err == nil && matchesVersionConstraints(version, constraints)
+}
+
+// Find the highest version, satisfying all the constraints.
+//
+// Accept candidate versions list and constraints list.
+// Return the highest satisfying version and boolean flag, whether such version was found.
+func findHighestMatchingVersion(versions []string, constraints []versionConstraint) (string, bool) {
+	best, found := "", false
+	for _, version := range versions {
+		if matchesVersionConstraints(version, constraints) && (!found || compareVersions(version, best) > 0) {
+			best, found = version, true
+		}
+	}
+	return best, found
+}
+
+// Find the version, the alias points to:
+//   - "latest" is the highest release version (pre-release versions are skipped)
+//   - "latest-rc" is the highest version, including pre-release versions (e.g. "26.0-rc1")
+//   - "previous-major" is the highest release version with major component lower than the "latest" one
+//
+// Accept version alias and available versions list.
+// Return the version and boolean flag, whether the version was found.
+func findAliasedVersion(alias string, versions []string) (string, bool) {
+	latest, found := "", false
+	for _, version := range versions {
+		if (alias == VERSION_ALIAS_LATEST_RC || !isPreReleaseVersion(version)) && (!found || compareVersions(version, latest) > 0) {
+			latest, found = version, true
+		}
+	}
+
+	if alias != VERSION_ALIAS_PREVIOUS_MAJOR || !found {
+		return latest, found
+	}
+
+	latestMajor, _ := splitVersionComponent(strings.Split(strings.TrimPrefix(latest, "v"), ".")[0])
+	return findHighestMatchingVersion(versions, []versionConstraint{{operator: "<", version: strconv.Itoa(latestMajor)}})
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a        string
+		b        string
+		expected int
+	}{
+		{a: "25.1", b: "25.1", expected: 0},
+		{a: "v25.1", b: "25.1", expected: 0},
+		{a: "25.10", b: "25.9", expected: 1},
+		{a: "3.21.12", b: "21.0", expected: -1},
+		{a: "25.1", b: "25.1.1", expected: -1},
+		{a: "25.0", b: "25.0-rc1", expected: 1},
+		{a: "25.0-rc1", b: "25.0-rc2", expected: -1},
+		{a: "25.0-rc10", b: "25.0-rc2", expected: 1},
+		{a: "1.0.0-alpha", b: "1.0.0-beta", expected: -1},
+		{a: "24.3.25", b: "24.3.7", expected: 1},
+	}
+
+	for _, test := range tests {
+		comparison := compareVersions(test.a, test.b)
+		if (comparison > 0) != (test.expected > 0) || (comparison < 0) != (test.expected < 0) {
+			t.Errorf("compareVersions(%s, %s) = %d, expected sign of %d", test.a, test.b, comparison, test.expected)
+		}
+		reversed := compareVersions(test.b, test.a)
+		if (reversed > 0) != (test.expected < 0) || (reversed < 0) != (test.expected > 0) {
+			t.Errorf("compareVersions(%s, %s) = %d, expected sign of %d", test.b, test.a, reversed, -test.expected)
+		}
+	}
+}
+
+func TestIsPreReleaseVersion(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected bool
+	}{
+		{version: "25.1", expected: false},
+		{version: "v1.34.2", expected: false},
+		{version: "26.0-rc1", expected: true},
+		{version: "v1.0.0-beta.1", expected: true},
+		{version: "latest", expected: true},
+	}
+
+	for _, test := range tests {
+		if actual := isPreReleaseVersion(test.version); actual != test.expected {
+			t.Errorf("isPreReleaseVersion(%s) = %v, expected %v", test.version, actual, test.expected)
+		}
+	}
+}
+
+func TestParseVersionConstraints(t *testing.T) {
+	tests := []struct {
+		expression string
+		expected   []versionConstraint
+		fails      bool
+	}{
+		{expression: "~25.1", expected: []versionConstraint{{">=", "25.1"}, {"<", "25.2"}}},
+		{expression: "~25", expected: []versionConstraint{{">=", "25"}, {"<", "26"}}},
+		{expression: "^24.1", expected: []versionConstraint{{">=", "24.1"}, {"<", "25"}}},
+		{expression: "^0.4.2", expected: []versionConstraint{{">=", "0.4.2"}, {"<", "0.5"}}},
+		{expression: "25.x", expected: []versionConstraint{{">=", "25"}, {"<", "26"}}},
+		{expression: "1.2.*", expected: []versionConstraint{{">=", "1.2"}, {"<", "1.3"}}},
+		{expression: "*", expected: nil},
+		{expression: ">=23, <26", expected: []versionConstraint{{">=", "23"}, {"<", "26"}}},
+		{expression: "25.1", expected: []versionConstraint{{"=", "25.1"}}},
+		{expression: "=v1.34.2", expected: []versionConstraint{{"=", "v1.34.2"}}},
+		{expression: ">=23,", fails: true},
+		{expression: "=>23", fails: true},
+		{expression: "~25.x", fails: true},
+		{expression: ">=abc", fails: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expression, func(t *testing.T) {
+			constraints, err := parseVersionConstraints(test.expression)
+			if test.fails && err == nil {
+				t.Errorf("expected error, got constraints %v", constraints)
+			} else if !test.fails && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			} else if !slices.Equal(constraints, test.expected) {
+				t.Errorf("got constraints %v, expected %v", constraints, test.expected)
+			}
+		})
+	}
+}
+
+func TestMatchesVersionExpression(t *testing.T) {
+	tests := []struct {
+		version    string
+		expression string
+		expected   bool
+	}{
+		{version: "25.3", expression: "~25.1", expected: false},
+		{version: "25.1.4", expression: "~25.1", expected: true},
+		{version: "25.0", expression: ">=25", expected: true},
+		{version: "26", expression: "<26.0", expected: false},
+		{version: "25.9", expression: ">=23,<26", expected: true},
+		{version: "v1.34.2", expression: "^1.30", expected: true},
+		{version: "2.0.0", expression: "^1.30", expected: false},
+		{version: "26.0-rc1", expression: ">=25", expected: false},
+		{version: "25.1", expression: "invalid", expected: false},
+	}
+
+	for _, test := range tests {
+		if actual := matchesVersionExpression(test.version, test.expression); actual != test.expected {
+			t.Errorf("matchesVersionExpression(%s, %s) = %v, expected %v", test.version, test.expression, actual, test.expected)
+		}
+	}
+}
+
+func TestFindAliasedVersion(t *testing.T) {
+	versions := []string{"v24.4", "v25.0-rc1", "v25.2", "v25.10", "v26.0-rc2", "v26.0-rc10"}
+
+	tests := []struct {
+		alias    string
+		versions []string
+		expected string
+		found    bool
+	}{
+		{alias: VERSION_ALIAS_LATEST, versions: versions, expected: "v25.10", found: true},
+		{alias: VERSION_ALIAS_LATEST_RC, versions: versions, expected: "v26.0-rc10", found: true},
+		{alias: VERSION_ALIAS_PREVIOUS_MAJOR, versions: versions, expected: "v24.4", found: true},
+		{alias: VERSION_ALIAS_PREVIOUS_MAJOR, versions: []string{"v25.1", "v25.2"}, found: false},
+		{alias: VERSION_ALIAS_LATEST, versions: []string{"v26.0-rc1"}, found: false},
+	}
+
+	for _, test := range tests {
+		version, found := findAliasedVersion(test.alias, test.versions)
+		if found != test.found || version != test.expected {
+			t.Errorf("findAliasedVersion(%s, %v) = (%s, %v), expected (%s, %v)", test.alias, test.versions, version, found, test.expected, test.found)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// Tool, whose release versions can be listed by "protogo versions" command.
+type versionedTool struct {
+	prefix       string
+	listVersions func(mirror string) ([]string, error)
+	mirror       func(config *Config) string
+	locked       func(lock *Lock) *ArchiveInfo
+}
+
+// All the tools, whose release versions can be listed, by name.
+var versionedTools = map[string]versionedTool{
+	PROTOC_EXECUTABLE: {
+		prefix:       PROTOC_CACHE_PREFIX,
+		listVersions: listProtocVersions,
+		mirror:       func(config *Config) string { return config.Protoc.Mirror },
+		locked:       func(lock *Lock) *ArchiveInfo { return lock.Protoc },
+	},
+	FLATC_EXECUTABLE: {
+		prefix:       FLATC_CACHE_PREFIX,
+		listVersions: listFlatcVersions,
+		mirror:       func(config *Config) string { return config.Flatc.Mirror },
+		locked:       func(lock *Lock) *ArchiveInfo { return lock.Flatc },
+	},
+}
+
+// Format boolean flag as a table cell ("yes" or empty string).
+//
+// Accept boolean flag.
+// Return table cell string.
+func formatFlag(flag bool) string {
+	if flag {
+		return "yes"
+	} else {
+		return ""
+	}
+}
+
+// Run "protogo versions" command.
+// Print all the release versions of the given tool ("protoc" or "flatc"), marking cached and locked versions and version aliases.
+// Only cached and locked versions are printed in offline mode.
+//
+// Accept configuration pointer and command arguments (tool name).
+// Return error.
+func versionsCommand(config *Config, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("exactly one tool name should be specified (use one of: %s, %s)", PROTOC_EXECUTABLE, FLATC_EXECUTABLE)
+	}
+
+	tool, ok := versionedTools[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown tool: %s (use one of: %s, %s)", args[0], PROTOC_EXECUTABLE, FLATC_EXECUTABLE)
+	}
+
+	cacheDir, err := getProtogoCacheDir(config.Cache)
+	if err != nil {
+		return fmt.Errorf("could not find or create cache directory: %v", err)
+	}
+
+	entries, err := listCacheEntries(*cacheDir, tool.prefix)
+	if err != nil {
+		return fmt.Errorf("could not list %s cache: %v", args[0], err)
+	}
+
+	lock, err := readLockFile(filepath.Join(config.ProjectDir, LOCK_FILE_NAME))
+	if err != nil {
+		return fmt.Errorf("could not read lock file: %v", err)
+	}
+
+	var versions []string
+	if !networkOffline {
+		versions, err = tool.listVersions(tool.mirror(config))
+		if err != nil {
+			return fmt.Errorf("could not list %s versions: %v", args[0], err)
+		}
+	}
+
+	cached := make(map[string]bool)
+	for _, entry := range entries {
+		cached[entry.version] = true
+		versions = append(versions, entry.version)
+	}
+
+	locked := ""
+	if info := tool.locked(lock); info != nil {
+		locked = strings.TrimPrefix(info.Version, "v")
+		versions = append(versions, locked)
+	}
+
+	for i, version := range versions {
+		versions[i] = strings.TrimPrefix(version, "v")
+	}
+	slices.Sort(versions)
+	versions = slices.Compact(versions)
+	slices.SortStableFunc(versions, func(a, b string) int {
+		return compareVersions(b, a)
+	})
+
+	aliases := make(map[string][]string)
+	for _, alias := range versionAliases {
+		if version, ok := findAliasedVersion(alias, versions); ok {
+			aliases[version] = append(aliases[version], alias)
+		}
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "VERSION\tPRE-RELEASE\tCACHED\tLOCKED\tALIASES")
+	for _, version := range versions {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", version, formatFlag(isPreReleaseVersion(version)), formatFlag(cached[version]), formatFlag(version == locked), strings.Join(aliases[version], ", "))
+	}
+	return writer.Flush()
+}